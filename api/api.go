@@ -4,7 +4,11 @@
 package api
 
 import (
+	"context"
+
 	"github.com/lino-network/lino-go/broadcast"
+	"github.com/lino-network/lino-go/errors"
+	"github.com/lino-network/lino-go/model"
 	"github.com/lino-network/lino-go/query"
 	"github.com/lino-network/lino-go/transport"
 )
@@ -14,24 +18,179 @@ import (
 type API struct {
 	*query.Query
 	*broadcast.Broadcast
+
+	// CheckFromApp makes Donate and DonateSync verify that fromApp is
+	// a registered developer before broadcasting, at the cost of one more query.
+	CheckFromApp bool
+
+	// DefaultFromApp is used by Donate and DonateSync when fromApp is empty.
+	DefaultFromApp string
+	// DefaultMemo is used by Donate, DonateSync and Transfer when memo is empty.
+	DefaultMemo string
+
+	transport *transport.Transport
 }
 
 // NewLinoAPIFromConfig initiates an instance of API using
 // configs from ~/.lino-go/config.json
 func NewLinoAPIFromConfig() *API {
 	transport := transport.NewTransportFromConfig()
-	return &API{
-		Query:     query.NewQuery(transport),
-		Broadcast: broadcast.NewBroadcast(transport),
-	}
+	return newAPI(transport)
 }
 
 // NewLinoAPIFromArgs initiates an instance of API using
 // chainID and nodeUrl that are passed in.
 func NewLinoAPIFromArgs(chainID, nodeUrl string) *API {
 	transport := transport.NewTransportFromArgs(chainID, nodeUrl)
+	return newAPI(transport)
+}
+
+// NewLinoAPIFromTransport initiates an instance of API using
+// a transport configured by the caller, e.g. with BeforeBroadcast set.
+func NewLinoAPIFromTransport(transport *transport.Transport) *API {
+	return newAPI(transport)
+}
+
+func newAPI(transport *transport.Transport) *API {
+	q := query.NewQuery(transport)
+	b := broadcast.NewBroadcast(transport)
+	b.SeqQuerier = q
 	return &API{
-		Query:     query.NewQuery(transport),
-		Broadcast: broadcast.NewBroadcast(transport),
+		Query:     q,
+		Broadcast: b,
+		transport: transport,
+	}
+}
+
+// VerifyNetwork returns a ChainIDMismatch error naming both chain IDs if the node
+// is on another chain than the configured chain ID, in which case every transaction
+// would fail CheckTx. It costs one status call, e.g. to run before the first broadcast.
+func (api *API) VerifyNetwork(ctx context.Context) error {
+	return api.transport.CheckChainID(ctx)
+}
+
+// Transfer sends a certain amount of LINO token from the sender to the receiver.
+// DefaultMemo is used if memo is empty.
+func (api *API) Transfer(ctx context.Context, sender, receiver, amount, memo,
+	privKeyHex string, seq int64) (*model.BroadcastResponse, error) {
+	return api.Broadcast.Transfer(ctx, sender, receiver, amount, api.memoOrDefault(memo), privKeyHex, seq)
+}
+
+// Donate adds a money donation to a post by a user.
+// DefaultFromApp and DefaultMemo are used if fromApp or memo is empty.
+// If CheckFromApp is set, fromApp must be a registered developer.
+func (api *API) Donate(ctx context.Context, username, author,
+	amount, postID, fromApp, memo string, privKeyHex string, seq int64) (*model.BroadcastResponse, error) {
+	fromApp, memo = api.fromAppOrDefault(fromApp), api.memoOrDefault(memo)
+	if err := api.checkFromApp(ctx, fromApp); err != nil {
+		return nil, err
+	}
+	return api.Broadcast.Donate(ctx, username, author, amount, postID, fromApp, memo, privKeyHex, seq)
+}
+
+// DonateSync adds a money donation to a post by a user and returns after pass checkTx.
+// DefaultFromApp and DefaultMemo are used if fromApp or memo is empty.
+// If CheckFromApp is set, fromApp must be a registered developer.
+func (api *API) DonateSync(ctx context.Context, username, author,
+	amount, postID, fromApp, memo string, privKeyHex string, seq int64) (*model.BroadcastResponse, error) {
+	fromApp, memo = api.fromAppOrDefault(fromApp), api.memoOrDefault(memo)
+	if err := api.checkFromApp(ctx, fromApp); err != nil {
+		return nil, err
+	}
+	return api.Broadcast.DonateSync(ctx, username, author, amount, postID, fromApp, memo, privKeyHex, seq)
+}
+
+// UpdatePostPartial updates only the fields set in update and keeps the
+// current values of the other fields, which are read from the blockchain first.
+func (api *API) UpdatePostPartial(ctx context.Context, author, postID string,
+	update model.PostUpdate, privKeyHex string, seq int64) (*model.BroadcastResponse, error) {
+	postInfo, err := api.GetPostInfo(ctx, author, postID)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := model.UpdatePostMsg{
+		Author:  author,
+		PostID:  postID,
+		Title:   postInfo.Title,
+		Content: postInfo.Content,
+		Links:   postInfo.Links,
+	}
+	if update.Title != nil {
+		msg.Title = *update.Title
+	}
+	if update.Content != nil {
+		msg.Content = *update.Content
+	}
+	if update.Links != nil {
+		msg.Links = update.Links
+	}
+	if len(msg.Links) == 0 {
+		msg.Links = nil
+	}
+
+	signer, err := transport.NewSignerFromHex(privKeyHex)
+	if err != nil {
+		return nil, errors.FailedToBroadcast(err.Error())
+	}
+	return api.BroadcastMsgWithSigner(ctx, msg, signer, seq)
+}
+
+// BroadcastIf broadcasts msg only if precondition returns nil for the state of
+// the signer of msg, e.g. to transfer only if the saving covers the amount.
+// The state is read at the latest height, so the bank and the meta are consistent,
+// but the state may still change before the transaction is committed.
+// An error of precondition is returned as the cause of a PreconditionFailed error.
+func (api *API) BroadcastIf(ctx context.Context, msg model.Msg, privKeyHex string, seq int64,
+	precondition func(state *model.AccountState) error) (*model.BroadcastResponse, error) {
+	username, ok := model.GetSigner(msg)
+	if !ok {
+		return nil, errors.InvalidArgf("BroadcastIf: unknown signer of msg %T", msg)
+	}
+	status, err := api.GetBlockStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	state, err := api.GetAccountStateAtHeight(ctx, username, status.LatestBlockHeight)
+	if err != nil {
+		return nil, err
+	}
+	if err := precondition(state); err != nil {
+		return nil, errors.PreconditionFailedf("precondition of %v at height %v failed", username, state.Height).AddCause(err)
+	}
+
+	signer, err := transport.NewSignerFromHex(privKeyHex)
+	if err != nil {
+		return nil, errors.FailedToBroadcast(err.Error())
+	}
+	return api.BroadcastMsgWithSigner(ctx, msg, signer, seq)
+}
+
+func (api *API) fromAppOrDefault(fromApp string) string {
+	if fromApp == "" {
+		return api.DefaultFromApp
+	}
+	return fromApp
+}
+
+func (api *API) memoOrDefault(memo string) string {
+	if memo == "" {
+		return api.DefaultMemo
+	}
+	return memo
+}
+
+func (api *API) checkFromApp(ctx context.Context, fromApp string) error {
+	if !api.CheckFromApp || fromApp == "" {
+		return nil
+	}
+
+	_, err := api.GetDeveloper(ctx, fromApp)
+	if err != nil {
+		if vErr, ok := err.(errors.Error); ok && vErr.CodeType() == errors.CodeEmptyResponse {
+			return errors.UnknownAppf("app %v is not a registered developer", fromApp)
+		}
+		return err
 	}
+	return nil
 }
@@ -5,7 +5,8 @@ package broadcast
 import (
 	"context"
 	"encoding/hex"
-	"strings"
+	"encoding/json"
+	"time"
 
 	"github.com/lino-network/lino-go/errors"
 	"github.com/lino-network/lino-go/model"
@@ -14,15 +15,53 @@ import (
 	ctypes "github.com/tendermint/tendermint/rpc/core/types"
 )
 
+// confirmationPollInterval is the interval between two block height checks
+// while waiting for confirmations, close to the block interval of Lino blockchain.
+const confirmationPollInterval = 3 * time.Second
+
+// maxUnconfirmedTxs is the max number of mempool transactions checked by ReplaceTx.
+const maxUnconfirmedTxs = 100
+
+// AutoSeq can be passed as seq to any broadcast method to use the next sequence
+// number of the signer on blockchain, see Broadcast.SeqQuerier.
+// The number is read from committed state, unless a later transaction of the
+// signer broadcast by the same Broadcast has been accepted into the mempool,
+// in which case the sequence number after that one is used.
+const AutoSeq int64 = -1
+
+// SeqQuerier returns the next sequence number of a user, e.g. query.Query.
+type SeqQuerier interface {
+	GetSeqNumber(ctx context.Context, username string) (int64, error)
+}
+
+// SigningKeyQuerier returns the keys of a user, e.g. query.Query.
+type SigningKeyQuerier interface {
+	GetAccountInfo(ctx context.Context, username string) (*model.AccountInfo, error)
+	GetGrantPubKey(ctx context.Context, username string, pubKeyHex string) (*model.GrantPubKey, error)
+}
+
 // Broadcast is a wrapper of broadcasting transactions to blockchain.
 type Broadcast struct {
 	transport *transport.Transport
+
+	// SeqQuerier is used to fetch the sequence number when AutoSeq is passed as seq.
+	SeqQuerier SeqQuerier
+
+	// SigningKeyChecker, if set, is used to check that the signing key is a key
+	// or a granted key of the signer before broadcasting, at the cost of more queries.
+	// A mismatch is returned as a WrongSigningKey error.
+	SigningKeyChecker SigningKeyQuerier
+
+	// queue serializes the broadcasts of each account, see accountQueue.
+	queue *accountQueue
 }
 
-// NewBroadcast returns an instance of Broadcast.
+// NewBroadcast returns an instance of Broadcast. Concurrent broadcasts of the
+// same account are serialized, broadcasts of different accounts run in parallel.
 func NewBroadcast(transport *transport.Transport) *Broadcast {
 	return &Broadcast{
 		transport: transport,
+		queue:     newAccountQueue(),
 	}
 }
 
@@ -55,7 +94,7 @@ func (broadcast *Broadcast) Register(ctx context.Context, referrer, registerFee,
 		NewTransactionPubKey: txPubKey,
 		NewAppPubKey:         appPubKey,
 	}
-	return broadcast.broadcastTransaction(ctx, msg, referrerPrivKeyHex, seq, "", false)
+	return broadcast.broadcastTransaction(ctx, msg, referrerPrivKeyHex, seq, "", transport.BroadcastCommit)
 }
 
 // Transfer sends a certain amount of LINO token from the sender to the receiver.
@@ -68,7 +107,7 @@ func (broadcast *Broadcast) Transfer(ctx context.Context, sender, receiver, amou
 		Amount:   amount,
 		Memo:     memo,
 	}
-	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", false)
+	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", transport.BroadcastCommit)
 }
 
 // Follow creates a social relationship between follower and followee.
@@ -79,7 +118,7 @@ func (broadcast *Broadcast) Follow(ctx context.Context, follower, followee,
 		Follower: follower,
 		Followee: followee,
 	}
-	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", false)
+	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", transport.BroadcastCommit)
 }
 
 // Unfollow revokes the social relationship between follower and followee.
@@ -90,7 +129,7 @@ func (broadcast *Broadcast) Unfollow(ctx context.Context, follower, followee,
 		Follower: follower,
 		Followee: followee,
 	}
-	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", false)
+	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", transport.BroadcastCommit)
 }
 
 // Claim claims rewards of a certain user.
@@ -100,7 +139,7 @@ func (broadcast *Broadcast) Claim(ctx context.Context, username,
 	msg := model.ClaimMsg{
 		Username: username,
 	}
-	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", false)
+	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", transport.BroadcastCommit)
 }
 
 // UpdateAccount updates account related info in jsonMeta which are not
@@ -112,7 +151,19 @@ func (broadcast *Broadcast) UpdateAccount(ctx context.Context, username, jsonMet
 		Username: username,
 		JSONMeta: jsonMeta,
 	}
-	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", false)
+	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", transport.BroadcastCommit)
+}
+
+// UpdateAccountWithMeta updates account related info in jsonMeta which are not
+// included in AccountInfo or AccountBank, e.g. a map[string]interface{} or a struct
+// with json tags. meta is marshaled to JSON before it composes UpdateAccountMsg.
+func (broadcast *Broadcast) UpdateAccountWithMeta(ctx context.Context, username string, meta interface{},
+	privKeyHex string, seq int64) (*model.BroadcastResponse, error) {
+	jsonMeta, err := json.Marshal(meta)
+	if err != nil {
+		return nil, errors.InvalidArgf("UpdateAccountWithMeta: failed to marshal meta").AddCause(err)
+	}
+	return broadcast.UpdateAccount(ctx, username, string(jsonMeta), privKeyHex, seq)
 }
 
 // Recover recovers all keys of a user in case of losing or compromising.
@@ -138,7 +189,7 @@ func (broadcast *Broadcast) Recover(ctx context.Context, username, newResetPubKe
 		NewTransactionPubKey: txPubKey,
 		NewAppPubKey:         appPubKey,
 	}
-	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", false)
+	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", transport.BroadcastCommit)
 }
 
 //
@@ -171,7 +222,7 @@ func (broadcast *Broadcast) CreatePost(ctx context.Context, author, postID, titl
 		Links:        mLinks,
 		RedistributionSplitRate: redistributionSplitRate,
 	}
-	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", false)
+	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", transport.BroadcastCommit)
 }
 
 // CreatePost creates a new post on blockchain.
@@ -200,7 +251,7 @@ func (broadcast *Broadcast) CreatePostSync(ctx context.Context, author, postID,
 		Links:        mLinks,
 		RedistributionSplitRate: redistributionSplitRate,
 	}
-	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", true)
+	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", transport.BroadcastSync)
 }
 
 // Donate adds a money donation to a post by a user.
@@ -215,7 +266,7 @@ func (broadcast *Broadcast) Donate(ctx context.Context, username, author,
 		FromApp:  fromApp,
 		Memo:     memo,
 	}
-	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", false)
+	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", transport.BroadcastCommit)
 }
 
 // Donate adds a money donation to a post by a user.
@@ -230,7 +281,7 @@ func (broadcast *Broadcast) DonateSync(ctx context.Context, username, author,
 		FromApp:  fromApp,
 		Memo:     memo,
 	}
-	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", true)
+	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", transport.BroadcastSync)
 }
 
 // ReportOrUpvote adds a report or upvote action to a post.
@@ -243,7 +294,7 @@ func (broadcast *Broadcast) ReportOrUpvote(ctx context.Context, username, author
 		PostID:   postID,
 		IsReport: isReport,
 	}
-	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", false)
+	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", transport.BroadcastCommit)
 }
 
 // DeletePost deletes a post from the blockchain. It doesn't actually
@@ -256,7 +307,7 @@ func (broadcast *Broadcast) DeletePost(ctx context.Context, author, postID,
 		Author: author,
 		PostID: postID,
 	}
-	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", false)
+	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", transport.BroadcastCommit)
 }
 
 // View increases the view count of a post by one.
@@ -268,7 +319,7 @@ func (broadcast *Broadcast) View(ctx context.Context, username, author, postID,
 		Author:   author,
 		PostID:   postID,
 	}
-	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", false)
+	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", transport.BroadcastCommit)
 }
 
 // UpdatePost updates post info with new data.
@@ -291,7 +342,7 @@ func (broadcast *Broadcast) UpdatePost(ctx context.Context, author, title, postI
 		Content: content,
 		Links:   mLinks,
 	}
-	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", false)
+	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", transport.BroadcastCommit)
 }
 
 //
@@ -314,7 +365,7 @@ func (broadcast *Broadcast) ValidatorDeposit(ctx context.Context, username, depo
 		ValPubKey: valPubKey,
 		Link:      link,
 	}
-	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", false)
+	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", transport.BroadcastCommit)
 }
 
 // ValidatorWithdraw withdraws part of LINO token from a validator's deposit,
@@ -326,7 +377,7 @@ func (broadcast *Broadcast) ValidatorWithdraw(ctx context.Context, username, amo
 		Username: username,
 		Amount:   amount,
 	}
-	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", false)
+	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", transport.BroadcastCommit)
 }
 
 // ValidatorRevoke revokes all deposited LINO token of a validator
@@ -337,7 +388,7 @@ func (broadcast *Broadcast) ValidatorRevoke(ctx context.Context, username,
 	msg := model.ValidatorRevokeMsg{
 		Username: username,
 	}
-	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", false)
+	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", transport.BroadcastCommit)
 }
 
 //
@@ -353,7 +404,7 @@ func (broadcast *Broadcast) StakeIn(ctx context.Context, username, deposit,
 		Username: username,
 		Deposit:  deposit,
 	}
-	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", false)
+	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", transport.BroadcastCommit)
 }
 
 // StakeOut withdraws part of LINO token from a voter's deposit.
@@ -364,7 +415,7 @@ func (broadcast *Broadcast) StakeOut(ctx context.Context, username, amount,
 		Username: username,
 		Amount:   amount,
 	}
-	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", false)
+	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", transport.BroadcastCommit)
 }
 
 // Delegate delegates a certain amount of LINO token of delegator to a voter, so
@@ -377,7 +428,7 @@ func (broadcast *Broadcast) Delegate(ctx context.Context, delegator, voter, amou
 		Voter:     voter,
 		Amount:    amount,
 	}
-	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", false)
+	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", transport.BroadcastCommit)
 }
 
 // DelegatorWithdraw withdraws part of delegated LINO token of a delegator
@@ -390,7 +441,7 @@ func (broadcast *Broadcast) DelegatorWithdraw(ctx context.Context, delegator, vo
 		Voter:     voter,
 		Amount:    amount,
 	}
-	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", false)
+	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", transport.BroadcastCommit)
 }
 
 // ClaimInterest claims interest of a certain user.
@@ -400,7 +451,7 @@ func (broadcast *Broadcast) ClaimInterest(ctx context.Context, username,
 	msg := model.ClaimInterestMsg{
 		Username: username,
 	}
-	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", false)
+	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", transport.BroadcastCommit)
 }
 
 //
@@ -418,7 +469,7 @@ func (broadcast *Broadcast) DeveloperRegister(ctx context.Context, username, dep
 		Description: description,
 		AppMetaData: appMetaData,
 	}
-	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", false)
+	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", transport.BroadcastCommit)
 }
 
 // DeveloperUpdate updates a developer  info on blockchain.
@@ -431,7 +482,7 @@ func (broadcast *Broadcast) DeveloperUpdate(ctx context.Context, username, websi
 		Description: description,
 		AppMetaData: appMetaData,
 	}
-	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", false)
+	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", transport.BroadcastCommit)
 }
 
 // DeveloperRevoke reovkes all deposited LINO token of a developer
@@ -442,7 +493,7 @@ func (broadcast *Broadcast) DeveloperRevoke(ctx context.Context, username,
 	msg := model.DeveloperRevokeMsg{
 		Username: username,
 	}
-	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", false)
+	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", transport.BroadcastCommit)
 }
 
 // GrantPermission grants a certain (e.g. App) permission to
@@ -456,7 +507,7 @@ func (broadcast *Broadcast) GrantPermission(ctx context.Context, username, autho
 		ValidityPeriodSec: validityPeriodSec,
 		GrantLevel:        grantLevel,
 	}
-	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", false)
+	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", transport.BroadcastCommit)
 }
 
 // PreAuthorizationPermission grants a PreAuthorization permission to
@@ -471,7 +522,7 @@ func (broadcast *Broadcast) PreAuthorizationPermission(ctx context.Context, user
 		Amount:            amount,
 	}
 
-	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", false)
+	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", transport.BroadcastCommit)
 }
 
 // RevokePermission revokes the permission given previously to a app.
@@ -487,7 +538,7 @@ func (broadcast *Broadcast) RevokePermission(ctx context.Context, username, pubK
 		Username: username,
 		PubKey:   pubKey,
 	}
-	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", false)
+	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", transport.BroadcastCommit)
 }
 
 //
@@ -502,7 +553,7 @@ func (broadcast *Broadcast) ProviderReport(ctx context.Context, username string,
 		Username: username,
 		Usage:    usage,
 	}
-	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", false)
+	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", transport.BroadcastCommit)
 }
 
 //
@@ -518,7 +569,7 @@ func (broadcast *Broadcast) ChangeEvaluateOfContentValueParam(ctx context.Contex
 		Parameter: parameter,
 		Reason:    reason,
 	}
-	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", false)
+	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", transport.BroadcastCommit)
 }
 
 // ChangeGlobalAllocationParam changes GlobalAllocationParam with new value.
@@ -530,7 +581,7 @@ func (broadcast *Broadcast) ChangeGlobalAllocationParam(ctx context.Context, cre
 		Parameter: parameter,
 		Reason:    reason,
 	}
-	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", false)
+	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", transport.BroadcastCommit)
 }
 
 // ChangeInfraInternalAllocationParam changes InfraInternalAllocationParam with new value.
@@ -543,7 +594,7 @@ func (broadcast *Broadcast) ChangeInfraInternalAllocationParam(ctx context.Conte
 		Parameter: parameter,
 		Reason:    reason,
 	}
-	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", false)
+	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", transport.BroadcastCommit)
 }
 
 // ChangeVoteParam changes VoteParam with new value.
@@ -555,7 +606,7 @@ func (broadcast *Broadcast) ChangeVoteParam(ctx context.Context, creator string,
 		Parameter: parameter,
 		Reason:    reason,
 	}
-	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", false)
+	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", transport.BroadcastCommit)
 }
 
 // ChangeProposalParam changes ProposalParam with new value.
@@ -567,7 +618,7 @@ func (broadcast *Broadcast) ChangeProposalParam(ctx context.Context, creator str
 		Parameter: parameter,
 		Reason:    reason,
 	}
-	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", false)
+	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", transport.BroadcastCommit)
 }
 
 // ChangeDeveloperParam changes DeveloperParam with new value.
@@ -579,7 +630,7 @@ func (broadcast *Broadcast) ChangeDeveloperParam(ctx context.Context, creator st
 		Parameter: parameter,
 		Reason:    reason,
 	}
-	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", false)
+	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", transport.BroadcastCommit)
 }
 
 // ChangeValidatorParam changes ValidatorParam with new value.
@@ -591,7 +642,7 @@ func (broadcast *Broadcast) ChangeValidatorParam(ctx context.Context, creator st
 		Parameter: parameter,
 		Reason:    reason,
 	}
-	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", false)
+	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", transport.BroadcastCommit)
 }
 
 // ChangeBandwidthParam changes BandwidthParam with new value.
@@ -603,7 +654,7 @@ func (broadcast *Broadcast) ChangeBandwidthParam(ctx context.Context, creator st
 		Parameter: parameter,
 		Reason:    reason,
 	}
-	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", false)
+	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", transport.BroadcastCommit)
 }
 
 // ChangeAccountParam changes AccountParam with new value.
@@ -615,7 +666,7 @@ func (broadcast *Broadcast) ChangeAccountParam(ctx context.Context, creator stri
 		Parameter: parameter,
 		Reason:    reason,
 	}
-	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", false)
+	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", transport.BroadcastCommit)
 }
 
 // ChangePostParam changes PostParam with new value.
@@ -627,7 +678,7 @@ func (broadcast *Broadcast) ChangePostParam(ctx context.Context, creator string,
 		Parameter: parameter,
 		Reason:    reason,
 	}
-	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", false)
+	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", transport.BroadcastCommit)
 }
 
 // DeletePostContent deletes the content of a post on blockchain, which is used
@@ -635,13 +686,12 @@ func (broadcast *Broadcast) ChangePostParam(ctx context.Context, creator string,
 // It composes DeletePostContentMsg and then broadcasts the transaction to blockchain.
 func (broadcast *Broadcast) DeletePostContent(ctx context.Context, creator, postAuthor,
 	postID, reason, privKeyHex string, seq int64) (*model.BroadcastResponse, error) {
-	permlink := string(string(postAuthor) + "#" + postID)
 	msg := model.DeletePostContentMsg{
 		Creator:  creator,
-		Permlink: permlink,
+		Permlink: model.Permlink(postAuthor, postID),
 		Reason:   reason,
 	}
-	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", false)
+	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", transport.BroadcastCommit)
 }
 
 // VoteProposal adds a vote to a certain proposal with agree/disagree.
@@ -653,7 +703,7 @@ func (broadcast *Broadcast) VoteProposal(ctx context.Context, voter, proposalID
 		ProposalID: proposalID,
 		Result:     result,
 	}
-	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", false)
+	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", transport.BroadcastCommit)
 }
 
 // UpgradeProtocol upgrades the protocol.
@@ -665,76 +715,414 @@ func (broadcast *Broadcast) UpgradeProtocol(ctx context.Context, creator, link,
 		Link:    link,
 		Reason:  reason,
 	}
-	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", false)
+	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", transport.BroadcastCommit)
+}
+
+// BroadcastMsgWithSigner signs msg with signer instead of a private key hex,
+// e.g. a hardware wallet, and then broadcasts the transaction to blockchain.
+func (broadcast *Broadcast) BroadcastMsgWithSigner(ctx context.Context, msg model.Msg,
+	signer transport.Signer, seq int64) (*model.BroadcastResponse, error) {
+	return broadcast.broadcastTransactionWithSigner(ctx, msg, signer, seq, "", transport.BroadcastCommit)
+}
+
+// ReplaceTx broadcasts msg signed with the sequence number seq of a transaction
+// broadcast before, e.g. one stuck in the mempool, and returns after CheckTx.
+// Lino blockchain charges no fees and its mempool doesn't replace transactions:
+// the mempool checks a transaction against the state after its pending transactions,
+// so seq stays taken while the original transaction is pending or once it is committed.
+// The replacement only succeeds if the original has been dropped, e.g. evicted from
+// the mempool, otherwise a TxNotReplaceable error is returned. The same is returned if
+// msg is the one of the original transaction, which signs to the same bytes.
+func (broadcast *Broadcast) ReplaceTx(ctx context.Context, msg model.Msg,
+	privKeyHex string, seq int64) (*model.BroadcastResponse, error) {
+	signer, err := transport.NewSignerFromHex(privKeyHex)
+	if err != nil {
+		return nil, errors.FailedToBroadcast(err.Error())
+	}
+	// signing is deterministic, and the node accepts a transaction it already
+	// has as if it were new, so the original one must be looked up first
+	txBytes, err := broadcast.transport.SignBuild(msg, signer, seq, "")
+	if err != nil {
+		return nil, err
+	}
+	known, err := broadcast.isTxKnown(ctx, txBytes)
+	if err != nil {
+		return nil, err
+	}
+	if known {
+		return nil, errors.TxNotReplaceablef("tx %v with seq %v is the pending or committed transaction itself",
+			transport.TxHash(txBytes), seq)
+	}
+
+	resp, err := broadcast.broadcastTransactionWithSigner(ctx, msg, signer, seq, "", transport.BroadcastSync)
+	if err != nil {
+		if vErr, ok := err.(errors.Error); ok && vErr.CodeType() == errors.CodeInvalidSequenceNumber {
+			return nil, errors.TxNotReplaceablef("seq %v is taken by a pending or committed transaction", seq).AddCause(err)
+		}
+		return nil, err
+	}
+	return resp, nil
+}
+
+// isTxKnown returns true if txBytes is committed or among the first
+// maxUnconfirmedTxs transactions in the mempool of the node.
+func (broadcast *Broadcast) isTxKnown(ctx context.Context, txBytes []byte) (bool, error) {
+	txHash := transport.TxHash(txBytes)
+	hash, _ := hex.DecodeString(txHash)
+	if _, err := broadcast.transport.QueryTx(ctx, hash); err == nil {
+		return true, nil
+	}
+	res, err := broadcast.transport.QueryUnconfirmedTxs(ctx, maxUnconfirmedTxs)
+	if err != nil {
+		return false, errors.QueryFailf("failed to check tx %v in mempool", txHash).AddCause(err)
+	}
+	for _, tx := range res.Txs {
+		if transport.TxHash(tx) == txHash {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// CapacityRetry configures BroadcastMsgWithCapacityRetry.
+type CapacityRetry struct {
+	// InitialWait is the wait before the first retry.
+	InitialWait time.Duration
+	// Factor multiplies the wait after each retry, a factor below 1 keeps the wait.
+	Factor float64
+	// MaxWait caps the wait between two retries.
+	MaxWait time.Duration
+	// MaxRetries is the max number of retries after the first broadcast.
+	MaxRetries int
+}
+
+// BroadcastMsgWithCapacityRetry signs msg with signer and broadcasts the transaction,
+// retrying while CheckTx rejects it because the transaction capacity of the signer is
+// not enough. Lino blockchain charges no fees, a transaction is paid by the capacity
+// of the account which recovers over time, so instead of bumping a fee the wait before
+// each retry grows by retry.Factor up to retry.MaxWait. The last error is returned
+// once retry.MaxRetries is reached.
+func (broadcast *Broadcast) BroadcastMsgWithCapacityRetry(ctx context.Context, msg model.Msg,
+	signer transport.Signer, seq int64, retry CapacityRetry) (*model.BroadcastResponse, error) {
+	wait := retry.InitialWait
+	for i := 0; ; i++ {
+		resp, err := broadcast.broadcastTransactionWithSigner(ctx, msg, signer, seq, "", transport.BroadcastCommit)
+		if err == nil || !isCapacityNotEnough(err) || i >= retry.MaxRetries {
+			return resp, err
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, errors.Timeout("capacity retry timeout").AddCause(ctx.Err())
+		}
+		wait = nextCapacityWait(wait, retry)
+	}
+}
+
+// isCapacityNotEnough returns true if CheckTx rejected the transaction because
+// the transaction capacity of the signer is not enough.
+func isCapacityNotEnough(err error) bool {
+	vErr, ok := err.(errors.Error)
+	return ok && vErr.CodeType() == errors.CodeCheckTxFail &&
+		model.RetrieveCodeFromBlockChainCode(vErr.BlockChainCode()) == uint32(errors.CodeAccountTPSCapacityNotEnough)
+}
+
+// nextCapacityWait returns the wait before the retry after the one waiting wait.
+func nextCapacityWait(wait time.Duration, retry CapacityRetry) time.Duration {
+	if retry.Factor > 1 {
+		wait = time.Duration(float64(wait) * retry.Factor)
+	}
+	if retry.MaxWait > 0 && wait > retry.MaxWait {
+		wait = retry.MaxWait
+	}
+	return wait
+}
+
+// BroadcastJSONMsg decodes jsonBytes, the amino JSON of a message, into the message
+// type registered under msgType, e.g. "lino/transfer", and broadcasts it.
+// Note that int64 fields are strings in amino JSON.
+func (broadcast *Broadcast) BroadcastJSONMsg(ctx context.Context, msgType string, jsonBytes []byte,
+	privKeyHex string, seq int64) (*model.BroadcastResponse, error) {
+	msg, err := broadcast.transport.DecodeJSONMsg(msgType, jsonBytes)
+	if err != nil {
+		return nil, err
+	}
+	return broadcast.broadcastTransaction(ctx, msg, privKeyHex, seq, "", transport.BroadcastCommit)
+}
+
+// BroadcastMsgBeforeHeight signs msg with signer and broadcasts the transaction
+// only if the latest block height is still below maxHeight.
+// Lino transactions carry no expiry, so the bound is only checked before broadcasting:
+// a transaction that has reached the mempool may still be committed after maxHeight.
+func (broadcast *Broadcast) BroadcastMsgBeforeHeight(ctx context.Context, msg model.Msg,
+	signer transport.Signer, seq, maxHeight int64) (*model.BroadcastResponse, error) {
+	status, err := broadcast.transport.QueryBlockStatus(ctx)
+	if err != nil {
+		return nil, errors.QueryFailf("BroadcastMsgBeforeHeight: failed to get block status").AddCause(err)
+	}
+	if status.SyncInfo.LatestBlockHeight >= maxHeight {
+		return nil, errors.TxExpiredf("latest block height %v reached max height %v",
+			status.SyncInfo.LatestBlockHeight, maxHeight)
+	}
+	return broadcast.broadcastTransactionWithSigner(ctx, msg, signer, seq, "", transport.BroadcastCommit)
+}
+
+// BroadcastMsgWithConfirmations signs msg with signer, broadcasts the transaction and,
+// after it is committed, waits until it is buried under confirmations blocks.
+func (broadcast *Broadcast) BroadcastMsgWithConfirmations(ctx context.Context, msg model.Msg,
+	signer transport.Signer, seq, confirmations int64) (*model.BroadcastResponse, error) {
+	resp, err := broadcast.broadcastTransactionWithSigner(ctx, msg, signer, seq, "", transport.BroadcastCommit)
+	if err != nil {
+		return nil, err
+	}
+	if err := broadcast.WaitForConfirmations(ctx, resp.CommitHash, confirmations); err != nil {
+		return resp, err
+	}
+	return resp, nil
+}
+
+// BroadcastAndWait signs msg with signer, broadcasts the transaction and waits
+// until it is committed. The response carries the height and time of the block,
+// the gas of the transaction and the events emitted by DeliverTx, e.g. the
+// permlink of a new post.
+func (broadcast *Broadcast) BroadcastAndWait(ctx context.Context, msg model.Msg,
+	signer transport.Signer, seq int64) (*model.CommittedTxResponse, error) {
+	resp, result, err := broadcast.broadcastAndCommit(ctx, msg, signer, seq, "")
+	if err != nil {
+		return nil, err
+	}
+	return &model.CommittedTxResponse{
+		BroadcastResponse: *resp,
+		Result:            result,
+	}, nil
+}
+
+// WaitForConfirmations polls the blockchain until the latest block height
+// exceeds the height of the committed transaction txHash by confirmations.
+// It returns a Timeout error if ctx is done before that.
+func (broadcast *Broadcast) WaitForConfirmations(ctx context.Context, txHash string, confirmations int64) error {
+	tx, err := broadcast.waitForTx(ctx, txHash)
+	if err != nil {
+		return err
+	}
+
+	for {
+		status, err := broadcast.transport.QueryBlockStatus(ctx)
+		if err == nil && status.SyncInfo.LatestBlockHeight >= tx.Height+confirmations {
+			return nil
+		}
+		if err := waitForNextPoll(ctx); err != nil {
+			return err
+		}
+	}
 }
 
 //
 // internal helper functions
 //
 func (broadcast *Broadcast) broadcastTransaction(ctx context.Context, msg model.Msg, privKeyHex string,
-	seq int64, memo string, checkTxOnly bool) (*model.BroadcastResponse, error) {
-	broadcastResp := &model.BroadcastResponse{}
+	seq int64, memo string, mode transport.BroadcastMode) (*model.BroadcastResponse, error) {
+	signer, err := transport.NewSignerFromHex(privKeyHex)
+	if err != nil {
+		return nil, errors.FailedToBroadcast(err.Error())
+	}
+	return broadcast.broadcastTransactionWithSigner(ctx, msg, signer, seq, memo, mode)
+}
 
-	var res interface{}
-	var err error
-	finishChan := make(chan bool)
+// broadcastTransactionWithSigner broadcasts msg signed by signer in mode, see transport.BroadcastMode.
+func (broadcast *Broadcast) broadcastTransactionWithSigner(ctx context.Context, msg model.Msg, signer transport.Signer,
+	seq int64, memo string, mode transport.BroadcastMode) (*model.BroadcastResponse, error) {
+	if mode == transport.BroadcastCommit {
+		resp, _, err := broadcast.broadcastAndCommit(ctx, msg, signer, seq, memo)
+		return resp, err
+	}
+
+	res, err := broadcast.signAndBroadcast(ctx, msg, signer, seq, memo, mode)
+	if err != nil {
+		return nil, err
+	}
+	return model.ParseBroadcastResult(res)
+}
+
+// broadcastAndCommit broadcasts the transaction and returns once it is committed,
+// with the time of its block and its DeliverTx result. If the same transaction
+// is already pending in the mempool, it waits for that one to be committed.
+func (broadcast *Broadcast) broadcastAndCommit(ctx context.Context, msg model.Msg, signer transport.Signer,
+	seq int64, memo string) (*model.BroadcastResponse, *model.TxResult, error) {
+	var resp *model.BroadcastResponse
+	var result *model.TxResult
+
+	res, err := broadcast.signAndBroadcast(ctx, msg, signer, seq, memo, transport.BroadcastCommit)
+	if vErr, ok := err.(errors.Error); ok && vErr.CodeType() == errors.CodeTxAlreadyPending {
+		txHash := transport.TxHash(vErr.RawData())
+		tx, err := broadcast.waitForTx(ctx, txHash)
+		if err != nil {
+			return nil, nil, err
+		}
+		if tx.TxResult.Code != uint32(0) {
+			return nil, nil, errors.DeliverTxFail("DeliverTx failed!").
+				AddBlockChainCode(tx.TxResult.Code).AddBlockChainLog(tx.TxResult.Log)
+		}
+		resp = &model.BroadcastResponse{CommitHash: txHash, Height: tx.Height}
+		result = model.NewTxResult(tx.TxResult)
+	} else {
+		if err != nil {
+			return nil, nil, err
+		}
+		if resp, err = model.ParseBroadcastResult(res); err != nil {
+			return nil, nil, err
+		}
+		commit, ok := res.(*ctypes.ResultBroadcastTxCommit)
+		if !ok {
+			return nil, nil, errors.FailedToBroadcastf("unexpected result %T of commit broadcast", res)
+		}
+		result = model.NewTxResult(commit.DeliverTx)
+	}
+
+	// the transaction is committed, so a failure to read the block only leaves BlockTime unset
+	if block, err := broadcast.transport.QueryBlock(ctx, resp.Height); err == nil {
+		resp.BlockTime = block.BlockMeta.Header.Time
+	}
+	return resp, result, nil
+}
+
+// signAndBroadcast signs msg with signer and broadcasts the transaction,
+// returning the raw result of the node. Broadcasts of the same signer are
+// serialized until their transaction reaches the mempool or fails, which
+// may be after ctx is done and signAndBroadcast has returned.
+func (broadcast *Broadcast) signAndBroadcast(ctx context.Context, msg model.Msg, signer transport.Signer,
+	seq int64, memo string, mode transport.BroadcastMode) (interface{}, error) {
+	if err := msg.ValidateBasic(); err != nil {
+		return nil, err
+	}
+
+	var slot *accountSlot
+	if username, ok := model.GetSigner(msg); ok && broadcast.queue != nil {
+		var err error
+		if slot, err = broadcast.queue.acquire(ctx, username); err != nil {
+			return nil, err
+		}
+	}
+	// until the broadcast starts, the turn is released on return,
+	// after that only once the broadcast is over, see below
+	broadcasting := false
+	defer func() {
+		if slot != nil && !broadcasting {
+			slot.release()
+		}
+	}()
+	if seq == AutoSeq {
+		var err error
+		if seq, err = broadcast.getSeqNumber(ctx, msg); err != nil {
+			return nil, err
+		}
+		if slot != nil && slot.nextSeq > seq {
+			seq = slot.nextSeq
+		}
+	}
+	if broadcast.SigningKeyChecker != nil {
+		if err := broadcast.checkSigningKey(ctx, msg, signer); err != nil {
+			return nil, err
+		}
+	}
+
+	type result struct {
+		res interface{}
+		err error
+	}
+	resultChan := make(chan result, 1)
+	broadcasting = true
 	go func() {
-		res, err = broadcast.transport.SignBuildBroadcast(msg, privKeyHex, seq, memo, checkTxOnly)
-		finishChan <- true
+		res, err := broadcast.transport.SignBuildBroadcastWithMode(ctx, msg, signer, seq, memo, mode)
+		// the transaction may reach the mempool even if ctx is done before,
+		// so the next broadcast of the account waits for the outcome
+		if slot != nil {
+			slot.record(seq, res, err)
+			slot.release()
+		}
+		resultChan <- result{res: res, err: err}
 	}()
 
+	var res interface{}
+	var err error
 	select {
-	case <-finishChan:
-		break
+	case r := <-resultChan:
+		res, err = r.res, r.err
 	case <-ctx.Done():
 		return nil, errors.Timeoutf("msg timeout: %v", msg).AddCause(ctx.Err())
 	}
 
 	if err != nil {
+		if vErr, ok := err.(errors.Error); ok &&
+			(vErr.CodeType() == errors.CodeTxAlreadyPending || vErr.CodeType() == errors.CodeTxTooLarge) {
+			return nil, err
+		}
 		return nil, errors.FailedToBroadcast(err.Error())
 	}
+	return res, nil
+}
 
-	if checkTxOnly {
-		res, ok := res.(*ctypes.ResultBroadcastTx)
-		if !ok {
-			return nil, errors.FailedToBroadcast("error to parse the broadcast response")
+// waitForTx polls the blockchain until the transaction txHash is committed.
+func (broadcast *Broadcast) waitForTx(ctx context.Context, txHash string) (*ctypes.ResultTx, error) {
+	hash, err := hex.DecodeString(txHash)
+	if err != nil {
+		return nil, errors.InvalidArgf("invalid tx hash %v", txHash).AddCause(err)
+	}
+	for {
+		if tx, err := broadcast.transport.QueryTx(ctx, hash); err == nil {
+			return tx, nil
 		}
-		code := retrieveCodeFromBlockChainCode(res.Code)
-		if err == nil && code == model.InvalidSeqErrCode {
-			return nil, errors.InvalidSequenceNumber("invalid seq").AddBlockChainCode(res.Code).AddBlockChainLog(res.Log)
+		if err := waitForNextPoll(ctx); err != nil {
+			return nil, err
 		}
+	}
+}
 
-		if res.Code != uint32(0) {
-			return nil, errors.CheckTxFail("CheckTx failed!").AddBlockChainCode(res.Code).AddBlockChainLog(res.Log)
-		}
-		if res.Code != uint32(0) {
-			return nil, errors.DeliverTxFail("DeliverTx failed!").AddBlockChainCode(res.Code).AddBlockChainLog(res.Log)
-		}
-		commitHash := hex.EncodeToString(res.Hash)
-		broadcastResp.CommitHash = strings.ToUpper(commitHash)
-	} else {
-		res, ok := res.(*ctypes.ResultBroadcastTxCommit)
-		if !ok {
-			return nil, errors.FailedToBroadcast("error to parse the broadcast response")
-		}
-		code := retrieveCodeFromBlockChainCode(res.CheckTx.Code)
-		if err == nil && code == model.InvalidSeqErrCode {
-			return nil, errors.InvalidSequenceNumber("invalid seq").AddBlockChainCode(res.CheckTx.Code).AddBlockChainLog(res.CheckTx.Log)
-		}
+func (broadcast *Broadcast) getSeqNumber(ctx context.Context, msg model.Msg) (int64, error) {
+	if broadcast.SeqQuerier == nil {
+		return 0, errors.InvalidArgf("AutoSeq: no SeqQuerier to fetch the sequence number")
+	}
+	signer, ok := model.GetSigner(msg)
+	if !ok {
+		return 0, errors.InvalidArgf("AutoSeq: unknown signer of msg %T", msg)
+	}
+	seq, err := broadcast.SeqQuerier.GetSeqNumber(ctx, signer)
+	if err != nil {
+		return 0, errors.QueryFailf("AutoSeq: failed to get sequence number of %v", signer).AddCause(err)
+	}
+	return seq, nil
+}
 
-		if res.CheckTx.Code != uint32(0) {
-			return nil, errors.CheckTxFail("CheckTx failed!").AddBlockChainCode(res.CheckTx.Code).AddBlockChainLog(res.CheckTx.Log)
-		}
-		if res.DeliverTx.Code != uint32(0) {
-			return nil, errors.DeliverTxFail("DeliverTx failed!").AddBlockChainCode(res.DeliverTx.Code).AddBlockChainLog(res.DeliverTx.Log)
-		}
-		commitHash := hex.EncodeToString(res.Hash)
-		broadcastResp.CommitHash = strings.ToUpper(commitHash)
+func (broadcast *Broadcast) checkSigningKey(ctx context.Context, msg model.Msg, signer transport.Signer) error {
+	username, ok := model.GetSigner(msg)
+	if !ok {
+		// the signer of msg is unknown, leave the check to the blockchain
+		return nil
+	}
+	info, err := broadcast.SigningKeyChecker.GetAccountInfo(ctx, username)
+	if err != nil {
+		return errors.QueryFailf("check signing key: failed to get account info of %v", username).AddCause(err)
 	}
 
-	return broadcastResp, nil
+	pubKeyHex := hex.EncodeToString(signer.PubKey().Bytes())
+	if info.HasKey(pubKeyHex) {
+		return nil
+	}
+	if _, err := broadcast.SigningKeyChecker.GetGrantPubKey(ctx, username, pubKeyHex); err != nil {
+		if vErr, ok := err.(errors.Error); ok && vErr.CodeType() == errors.CodeEmptyResponse {
+			return errors.WrongSigningKeyf("signing key %v is not a key of %v", pubKeyHex, username)
+		}
+		return errors.QueryFailf("check signing key: failed to get granted key of %v", username).AddCause(err)
+	}
+	return nil
 }
 
-func retrieveCodeFromBlockChainCode(bcCode uint32) uint32 {
-	return bcCode & 0xff
+func waitForNextPoll(ctx context.Context) error {
+	select {
+	case <-time.After(confirmationPollInterval):
+		return nil
+	case <-ctx.Done():
+		return errors.Timeout("wait for confirmations timeout").AddCause(ctx.Err())
+	}
 }
@@ -0,0 +1,301 @@
+package broadcast
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lino-network/lino-go/errors"
+	"github.com/lino-network/lino-go/model"
+	"github.com/lino-network/lino-go/transport"
+
+	"github.com/tendermint/tendermint/crypto/secp256k1"
+	cmn "github.com/tendermint/tendermint/libs/common"
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	tmtypes "github.com/tendermint/tendermint/types"
+)
+
+type fakeSeqQuerier map[string]int64
+
+func (q fakeSeqQuerier) GetSeqNumber(ctx context.Context, username string) (int64, error) {
+	seq, ok := q[username]
+	if !ok {
+		return 0, errors.EmptyResponse("no account")
+	}
+	return seq, nil
+}
+
+func TestGetSeqNumber(t *testing.T) {
+	querier := fakeSeqQuerier{"user1": 7}
+
+	testCases := map[string]struct {
+		querier        SeqQuerier
+		msg            model.Msg
+		expectSeq      int64
+		expectCodeType errors.CodeType
+	}{
+		"signer found": {
+			querier:   querier,
+			msg:       model.TransferMsg{Sender: "user1", Receiver: "user2"},
+			expectSeq: 7,
+		},
+		"signer not found": {
+			querier:        querier,
+			msg:            model.FollowMsg{Follower: "user2", Followee: "user1"},
+			expectCodeType: errors.CodeQueryFail,
+		},
+		"no querier": {
+			msg:            model.TransferMsg{Sender: "user1", Receiver: "user2"},
+			expectCodeType: errors.CodeInvalidArg,
+		},
+	}
+
+	for testName, tc := range testCases {
+		broadcast := &Broadcast{SeqQuerier: tc.querier}
+		seq, err := broadcast.getSeqNumber(context.Background(), tc.msg)
+		if tc.expectCodeType == errors.CodeOK {
+			if err != nil {
+				t.Errorf("%s: failed to get seq, got err %v", testName, err)
+			} else if seq != tc.expectSeq {
+				t.Errorf("%s: diff seq, got %v, want %v", testName, seq, tc.expectSeq)
+			}
+			continue
+		}
+		if vErr, ok := err.(errors.Error); !ok || vErr.CodeType() != tc.expectCodeType {
+			t.Errorf("%s: diff err, got %v, want code %v", testName, err, tc.expectCodeType)
+		}
+	}
+}
+
+type fakeSigningKeyQuerier struct {
+	infos   map[string]*model.AccountInfo
+	granted map[string]bool
+}
+
+func (q fakeSigningKeyQuerier) GetAccountInfo(ctx context.Context, username string) (*model.AccountInfo, error) {
+	info, ok := q.infos[username]
+	if !ok {
+		return nil, errors.EmptyResponse("no account")
+	}
+	return info, nil
+}
+
+func (q fakeSigningKeyQuerier) GetGrantPubKey(
+	ctx context.Context, username string, pubKeyHex string) (*model.GrantPubKey, error) {
+	if !q.granted[username+pubKeyHex] {
+		return nil, errors.EmptyResponse("no granted key")
+	}
+	return &model.GrantPubKey{Username: username}, nil
+}
+
+func TestCheckSigningKey(t *testing.T) {
+	txPrivKey := secp256k1.GenPrivKey()
+	grantedPrivKey := secp256k1.GenPrivKey()
+	otherPrivKey := secp256k1.GenPrivKey()
+	querier := fakeSigningKeyQuerier{
+		infos: map[string]*model.AccountInfo{
+			"user1": {Username: "user1", TransactionKey: txPrivKey.PubKey()},
+		},
+		granted: map[string]bool{
+			"user1" + hex.EncodeToString(grantedPrivKey.PubKey().Bytes()): true,
+		},
+	}
+
+	testCases := map[string]struct {
+		username       string
+		privKeyHex     string
+		expectCodeType errors.CodeType
+	}{
+		"transaction key": {
+			username:   "user1",
+			privKeyHex: hex.EncodeToString(txPrivKey.Bytes()),
+		},
+		"granted key": {
+			username:   "user1",
+			privKeyHex: hex.EncodeToString(grantedPrivKey.Bytes()),
+		},
+		"other key": {
+			username:       "user1",
+			privKeyHex:     hex.EncodeToString(otherPrivKey.Bytes()),
+			expectCodeType: errors.CodeWrongSigningKey,
+		},
+		"unknown account": {
+			username:       "user2",
+			privKeyHex:     hex.EncodeToString(txPrivKey.Bytes()),
+			expectCodeType: errors.CodeQueryFail,
+		},
+	}
+
+	broadcast := &Broadcast{SigningKeyChecker: querier}
+	for testName, tc := range testCases {
+		signer, err := transport.NewSignerFromHex(tc.privKeyHex)
+		if err != nil {
+			t.Fatalf("%s: failed to create signer, got err %v", testName, err)
+		}
+		err = broadcast.checkSigningKey(context.Background(), model.ClaimMsg{Username: tc.username}, signer)
+		if tc.expectCodeType == errors.CodeOK {
+			if err != nil {
+				t.Errorf("%s: failed to check signing key, got err %v", testName, err)
+			}
+			continue
+		}
+		if vErr, ok := err.(errors.Error); !ok || vErr.CodeType() != tc.expectCodeType {
+			t.Errorf("%s: diff err, got %v, want code %v", testName, err, tc.expectCodeType)
+		}
+	}
+}
+
+type unsignedMsg struct{}
+
+func (msg unsignedMsg) Type() string         { return "unsigned" }
+func (msg unsignedMsg) ValidateBasic() error { return nil }
+
+func TestBroadcasterUnknownSigner(t *testing.T) {
+	broadcaster := NewBroadcaster(NewBroadcast(transport.NewTransportFromArgs("test-chain", "")), 1)
+	results := broadcaster.Broadcast(context.Background(), []BroadcastJob{{Msg: unsignedMsg{}, Seq: 1}})
+	if len(results) != 1 {
+		t.Fatalf("diff number of results, got %v, want 1", len(results))
+	}
+	if vErr, ok := results[0].Err.(errors.Error); !ok || vErr.CodeType() != errors.CodeInvalidArg {
+		t.Errorf("expect InvalidArg error, got %v", results[0].Err)
+	}
+}
+
+func TestNextCapacityWait(t *testing.T) {
+	testCases := map[string]struct {
+		wait       time.Duration
+		retry      CapacityRetry
+		expectWait time.Duration
+	}{
+		"grow by factor": {
+			wait:       time.Second,
+			retry:      CapacityRetry{Factor: 2, MaxWait: time.Minute},
+			expectWait: 2 * time.Second,
+		},
+		"capped by max wait": {
+			wait:       40 * time.Second,
+			retry:      CapacityRetry{Factor: 2, MaxWait: time.Minute},
+			expectWait: time.Minute,
+		},
+		"factor below one keeps the wait": {
+			wait:       time.Second,
+			retry:      CapacityRetry{Factor: 0.5},
+			expectWait: time.Second,
+		},
+		"no max wait": {
+			wait:       time.Hour,
+			retry:      CapacityRetry{Factor: 1.5},
+			expectWait: 90 * time.Minute,
+		},
+	}
+
+	for testName, tc := range testCases {
+		if wait := nextCapacityWait(tc.wait, tc.retry); wait != tc.expectWait {
+			t.Errorf("%s: diff wait, got %v, want %v", testName, wait, tc.expectWait)
+		}
+	}
+}
+
+func TestIsCapacityNotEnough(t *testing.T) {
+	capacityCode := uint32(errors.LinoErrorCodeSpace)<<16 | uint32(errors.CodeAccountTPSCapacityNotEnough)
+	testCases := map[string]struct {
+		err    error
+		expect bool
+	}{
+		"capacity not enough": {
+			err:    errors.CheckTxFail("CheckTx failed!").AddBlockChainCode(capacityCode),
+			expect: true,
+		},
+		"other CheckTx code": {
+			err:    errors.CheckTxFail("CheckTx failed!").AddBlockChainCode(uint32(errors.CodeAccountNotFound)),
+			expect: false,
+		},
+		"capacity code of DeliverTx": {
+			err:    errors.DeliverTxFail("DeliverTx failed!").AddBlockChainCode(capacityCode),
+			expect: false,
+		},
+		"nil": {
+			err:    nil,
+			expect: false,
+		},
+	}
+
+	for testName, tc := range testCases {
+		if got := isCapacityNotEnough(tc.err); got != tc.expect {
+			t.Errorf("%s: diff result, got %v, want %v", testName, got, tc.expect)
+		}
+	}
+}
+
+// inCacheClient has txs pending in its mempool and rejects them as in cache
+// when broadcast again, other transactions are accepted.
+type inCacheClient struct {
+	rpcclient.Client
+	txs []tmtypes.Tx
+}
+
+func (c inCacheClient) Tx(hash []byte, prove bool) (*ctypes.ResultTx, error) {
+	return nil, fmt.Errorf("Tx (%X) not found", hash)
+}
+
+func (c inCacheClient) UnconfirmedTxs(limit int) (*ctypes.ResultUnconfirmedTxs, error) {
+	return &ctypes.ResultUnconfirmedTxs{N: len(c.txs), Txs: c.txs}, nil
+}
+
+func (c inCacheClient) NumUnconfirmedTxs() (*ctypes.ResultUnconfirmedTxs, error) {
+	return &ctypes.ResultUnconfirmedTxs{N: len(c.txs)}, nil
+}
+
+func (c inCacheClient) BroadcastTxSync(tx tmtypes.Tx) (*ctypes.ResultBroadcastTx, error) {
+	for _, pending := range c.txs {
+		if bytes.Equal(pending, tx) {
+			return nil, fmt.Errorf("Error on broadcastTxSync: Tx already exists in cache")
+		}
+	}
+	return &ctypes.ResultBroadcastTx{Hash: cmn.HexBytes(tx.Hash())}, nil
+}
+
+func TestReplaceTx(t *testing.T) {
+	privKeyHex := hex.EncodeToString(secp256k1.GenPrivKey().Bytes())
+	signer, err := transport.NewSignerFromHex(privKeyHex)
+	if err != nil {
+		t.Fatalf("failed to create signer, got err %v", err)
+	}
+	pendingMsg := model.FollowMsg{Follower: "user1", Followee: "user2"}
+	pending, err := transport.NewTransportFromArgs("test-chain", "").SignBuild(pendingMsg, signer, 5, "")
+	if err != nil {
+		t.Fatalf("failed to sign pending tx, got err %v", err)
+	}
+
+	testCases := map[string]struct {
+		msg       model.Msg
+		expectErr errors.CodeType
+	}{
+		"same msg as the pending tx": {
+			msg:       pendingMsg,
+			expectErr: errors.CodeTxNotReplaceable,
+		},
+		"different msg": {
+			msg: model.FollowMsg{Follower: "user1", Followee: "user3"},
+		},
+	}
+
+	for testName, tc := range testCases {
+		client := inCacheClient{txs: []tmtypes.Tx{pending}}
+		broadcast := NewBroadcast(transport.NewTransportFromClient("test-chain", client))
+		_, err := broadcast.ReplaceTx(context.Background(), tc.msg, privKeyHex, 5)
+		if tc.expectErr != errors.CodeOK {
+			if vErr, ok := err.(errors.Error); !ok || vErr.CodeType() != tc.expectErr {
+				t.Errorf("%s: diff err, got %v, want code %v", testName, err, tc.expectErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: expect success, got err %v", testName, err)
+		}
+	}
+}
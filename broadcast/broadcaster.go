@@ -0,0 +1,137 @@
+package broadcast
+
+import (
+	"context"
+	"sync"
+
+	"github.com/lino-network/lino-go/errors"
+	"github.com/lino-network/lino-go/model"
+	"github.com/lino-network/lino-go/transport"
+)
+
+// BroadcastJob is a message waiting to be signed and broadcast by a Broadcaster.
+// Jobs are grouped by the signer of Msg, see model.GetSigner.
+type BroadcastJob struct {
+	Msg        model.Msg
+	PrivKeyHex string
+	Seq        int64
+	Memo       string
+}
+
+// BroadcastResult is the outcome of a single BroadcastJob.
+type BroadcastResult struct {
+	Job      BroadcastJob
+	Response *model.BroadcastResponse
+	Err      error
+}
+
+// Broadcaster broadcasts batches of messages with bounded concurrency.
+// Jobs of the same account are broadcast one by one in the given order to
+// keep sequence numbers monotonic, while different accounts run in parallel.
+type Broadcaster struct {
+	broadcast   *Broadcast
+	concurrency int
+}
+
+// NewBroadcaster returns an instance of Broadcaster which works on at most
+// concurrency accounts at the same time.
+func NewBroadcaster(broadcast *Broadcast, concurrency int) *Broadcaster {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	return &Broadcaster{
+		broadcast:   broadcast,
+		concurrency: concurrency,
+	}
+}
+
+// Broadcast broadcasts all jobs and returns one result per job, in the same order as jobs.
+// Once a job of an account fails, the remaining jobs of that account are skipped
+// since their sequence numbers can no longer be valid.
+func (b *Broadcaster) Broadcast(ctx context.Context, jobs []BroadcastJob) []BroadcastResult {
+	results := make([]BroadcastResult, len(jobs))
+
+	var usernames []string
+	jobsOfUser := make(map[string][]int)
+	for i, job := range jobs {
+		results[i].Job = job
+		username, ok := model.GetSigner(job.Msg)
+		if !ok {
+			results[i].Err = errors.InvalidArgf("unknown signer of msg %T", job.Msg)
+			continue
+		}
+		if _, ok := jobsOfUser[username]; !ok {
+			usernames = append(usernames, username)
+		}
+		jobsOfUser[username] = append(jobsOfUser[username], i)
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, b.concurrency)
+	for _, username := range usernames {
+		wg.Add(1)
+		go func(indexes []int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			b.broadcastInOrder(ctx, results, indexes)
+		}(jobsOfUser[username])
+	}
+	wg.Wait()
+
+	return results
+}
+
+func (b *Broadcaster) broadcastInOrder(ctx context.Context, results []BroadcastResult, indexes []int) {
+	var failed error
+	for _, i := range indexes {
+		job := results[i].Job
+		username, _ := model.GetSigner(job.Msg)
+		if failed != nil {
+			results[i].Err = errors.FailedToBroadcastf(
+				"skip seq %v of %v: previous transaction failed", job.Seq, username).AddCause(failed)
+			continue
+		}
+		if ctx.Err() != nil {
+			failed = errors.Timeoutf("skip seq %v of %v", job.Seq, username).AddCause(ctx.Err())
+			results[i].Err = failed
+			continue
+		}
+
+		resp, err := b.broadcast.broadcastTransaction(ctx, job.Msg, job.PrivKeyHex, job.Seq, job.Memo, transport.BroadcastCommit)
+		results[i].Response = resp
+		results[i].Err = err
+		if err != nil {
+			failed = err
+		}
+	}
+}
+
+// MultiTransfer transfers to each output from sender, one transaction per output
+// with sequence numbers starting from seq, and returns one result per output.
+// All outputs are validated before the first broadcast. TransferMsg only takes
+// a username as receiver, so outputs with an address are rejected as InvalidArg.
+// As with Broadcaster, the remaining transfers are skipped once one fails.
+func (broadcast *Broadcast) MultiTransfer(ctx context.Context, sender string, outputs []model.Output,
+	memo, privKeyHex string, seq int64) ([]BroadcastResult, error) {
+	jobs := make([]BroadcastJob, 0, len(outputs))
+	for i, output := range outputs {
+		if err := output.ValidateBasic(); err != nil {
+			return nil, errors.InvalidArgf("MultiTransfer: invalid output %v", i).AddCause(err)
+		}
+		if output.Address != "" {
+			return nil, errors.InvalidArgf("MultiTransfer: output %v: transfer to an address is not supported", i)
+		}
+		jobs = append(jobs, BroadcastJob{
+			Msg: model.TransferMsg{
+				Sender:   sender,
+				Receiver: output.Username,
+				Amount:   output.Amount,
+				Memo:     memo,
+			},
+			PrivKeyHex: privKeyHex,
+			Seq:        seq + int64(i),
+		})
+	}
+	return NewBroadcaster(broadcast, 1).Broadcast(ctx, jobs), nil
+}
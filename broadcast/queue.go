@@ -0,0 +1,91 @@
+package broadcast
+
+import (
+	"context"
+	"sync"
+
+	"github.com/lino-network/lino-go/errors"
+	"github.com/lino-network/lino-go/model"
+
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+)
+
+// accountQueue serializes the broadcasts of each account, so that concurrent
+// broadcasts of one account reach the mempool one by one while different
+// accounts proceed in parallel. It also remembers the sequence number after
+// the last transaction of each account accepted by CheckTx, which AutoSeq
+// prefers over the committed one still lagging behind the mempool.
+type accountQueue struct {
+	mu       sync.Mutex
+	accounts map[string]*accountSlot
+}
+
+// accountSlot is the turn of one account, held by at most one broadcast.
+type accountSlot struct {
+	// turn holds a token while no broadcast of the account is running.
+	turn chan struct{}
+	// nextSeq is the sequence number after the last accepted transaction, 0 if unknown.
+	nextSeq int64
+}
+
+func newAccountQueue() *accountQueue {
+	return &accountQueue{
+		accounts: make(map[string]*accountSlot),
+	}
+}
+
+// acquire waits for the turn of username, it returns a Timeout error if ctx is done first.
+// The slot must be released once the broadcast has reached the mempool or failed.
+func (q *accountQueue) acquire(ctx context.Context, username string) (*accountSlot, error) {
+	q.mu.Lock()
+	slot, ok := q.accounts[username]
+	if !ok {
+		slot = &accountSlot{turn: make(chan struct{}, 1)}
+		slot.turn <- struct{}{}
+		q.accounts[username] = slot
+	}
+	q.mu.Unlock()
+
+	select {
+	case <-slot.turn:
+		return slot, nil
+	case <-ctx.Done():
+		return nil, errors.Timeoutf("wait for the broadcasts of %v timeout", username).AddCause(ctx.Err())
+	}
+}
+
+// release passes the turn to the next broadcast of the account.
+func (slot *accountSlot) release() {
+	slot.turn <- struct{}{}
+}
+
+// record updates nextSeq with the outcome of broadcasting a transaction signed with seq.
+// It must be called while holding the turn.
+func (slot *accountSlot) record(seq int64, res interface{}, err error) {
+	if vErr, ok := err.(errors.Error); ok && vErr.CodeType() == errors.CodeTxAlreadyPending {
+		slot.nextSeq = seq + 1
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	var code uint32
+	switch res := res.(type) {
+	case *ctypes.ResultBroadcastTx:
+		// an async broadcast always reports 0, the transaction is assumed to pass CheckTx
+		code = res.Code
+	case *ctypes.ResultBroadcastTxCommit:
+		code = res.CheckTx.Code
+	default:
+		return
+	}
+	switch {
+	case code == uint32(0):
+		slot.nextSeq = seq + 1
+	case model.RetrieveCodeFromBlockChainCode(code) == model.InvalidSeqErrCode:
+		// the remembered sequence number is wrong, e.g. a transaction was
+		// dropped from the mempool, so fall back to the committed one
+		slot.nextSeq = 0
+	}
+}
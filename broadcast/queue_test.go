@@ -0,0 +1,158 @@
+package broadcast
+
+import (
+	"context"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/lino-network/lino-go/errors"
+	"github.com/lino-network/lino-go/model"
+	"github.com/lino-network/lino-go/transport"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/crypto/secp256k1"
+	cmn "github.com/tendermint/tendermint/libs/common"
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	tmtypes "github.com/tendermint/tendermint/types"
+)
+
+func TestAccountQueue(t *testing.T) {
+	queue := newAccountQueue()
+	slot, err := queue.acquire(context.Background(), "user1")
+	if err != nil {
+		t.Fatalf("failed to acquire user1, got err %v", err)
+	}
+
+	// other accounts are not blocked
+	other, err := queue.acquire(context.Background(), "user2")
+	if err != nil {
+		t.Fatalf("failed to acquire user2, got err %v", err)
+	}
+	other.release()
+
+	// the same account waits for the turn
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := queue.acquire(ctx, "user1"); err == nil {
+		t.Errorf("acquire user1 twice: expect timeout")
+	} else if vErr, ok := err.(errors.Error); !ok || vErr.CodeType() != errors.CodeTimeout {
+		t.Errorf("acquire user1 twice: diff err, got %v", err)
+	}
+
+	slot.nextSeq = 8
+	slot.release()
+	again, err := queue.acquire(context.Background(), "user1")
+	if err != nil {
+		t.Fatalf("failed to acquire user1 after release, got err %v", err)
+	}
+	if again.nextSeq != 8 {
+		t.Errorf("diff next seq, got %v, want %v", again.nextSeq, 8)
+	}
+	again.release()
+}
+
+func TestAccountSlotRecord(t *testing.T) {
+	seqCode := uint32(errors.LinoErrorCodeSpace)<<16 | model.InvalidSeqErrCode
+	testCases := map[string]struct {
+		res           interface{}
+		err           error
+		expectNextSeq int64
+	}{
+		"sync accepted": {
+			res:           &ctypes.ResultBroadcastTx{Code: 0},
+			expectNextSeq: 6,
+		},
+		"commit accepted": {
+			res:           &ctypes.ResultBroadcastTxCommit{CheckTx: abci.ResponseCheckTx{Code: 0}},
+			expectNextSeq: 6,
+		},
+		"already pending": {
+			err:           errors.TxAlreadyPending("tx already exists in cache"),
+			expectNextSeq: 6,
+		},
+		"invalid sequence": {
+			res:           &ctypes.ResultBroadcastTx{Code: seqCode},
+			expectNextSeq: 0,
+		},
+		"other CheckTx code": {
+			res:           &ctypes.ResultBroadcastTx{Code: uint32(errors.CodeAccountNotFound)},
+			expectNextSeq: 3,
+		},
+		"broadcast failed": {
+			err:           errors.FailedToBroadcast("connection refused"),
+			expectNextSeq: 3,
+		},
+	}
+
+	for testName, tc := range testCases {
+		slot := &accountSlot{nextSeq: 3}
+		slot.record(5, tc.res, tc.err)
+		if slot.nextSeq != tc.expectNextSeq {
+			t.Errorf("%s: diff next seq, got %v, want %v", testName, slot.nextSeq, tc.expectNextSeq)
+		}
+	}
+}
+
+// blockingClient hands each broadcast tx to txs and accepts it once unblock is closed.
+type blockingClient struct {
+	rpcclient.Client
+	txs     chan []byte
+	unblock chan struct{}
+}
+
+func (c blockingClient) BroadcastTxSync(tx tmtypes.Tx) (*ctypes.ResultBroadcastTx, error) {
+	c.txs <- tx
+	<-c.unblock
+	return &ctypes.ResultBroadcastTx{Hash: cmn.HexBytes(tx.Hash())}, nil
+}
+
+func TestSignAndBroadcastTimeoutKeepsTurn(t *testing.T) {
+	client := blockingClient{txs: make(chan []byte, 2), unblock: make(chan struct{})}
+	broadcast := NewBroadcast(transport.NewTransportFromClient("test-chain", client))
+	broadcast.SeqQuerier = fakeSeqQuerier{"user1": 5}
+	signer, err := transport.NewSignerFromHex(hex.EncodeToString(secp256k1.GenPrivKey().Bytes()))
+	if err != nil {
+		t.Fatalf("failed to create signer, got err %v", err)
+	}
+	msg := model.FollowMsg{Follower: "user1", Followee: "user2"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := broadcast.broadcastTransactionWithSigner(ctx, msg, signer, AutoSeq, "", transport.BroadcastSync)
+		firstErr <- err
+	}()
+	first := <-client.txs
+	cancel()
+	if err := <-firstErr; err == nil {
+		t.Fatalf("first broadcast: expect timeout")
+	} else if vErr, ok := err.(errors.Error); !ok || vErr.CodeType() != errors.CodeTimeout {
+		t.Fatalf("first broadcast: diff err, got %v", err)
+	}
+
+	secondErr := make(chan error, 1)
+	go func() {
+		_, err := broadcast.broadcastTransactionWithSigner(context.Background(), msg, signer, AutoSeq, "", transport.BroadcastSync)
+		secondErr <- err
+	}()
+	select {
+	case <-client.txs:
+		t.Fatalf("second broadcast doesn't wait for the first one")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(client.unblock)
+	second := <-client.txs
+	if err := <-secondErr; err != nil {
+		t.Fatalf("second broadcast: got err %v", err)
+	}
+	// MarshalSignedTx rejects a tx signed with another sequence number
+	if _, err := transport.MarshalSignedTx(first, "test-chain", 5); err != nil {
+		t.Errorf("first broadcast: diff seq, got err %v", err)
+	}
+	if _, err := transport.MarshalSignedTx(second, "test-chain", 6); err != nil {
+		t.Errorf("second broadcast: diff seq, got err %v", err)
+	}
+}
@@ -14,4 +14,17 @@ const (
 	CodeInvalidSequenceNumber
 	CodeEmptyResponse // 10
 	CodeTimeout
+	CodeUnknownApp
+	CodeTxExpired
+	CodeNodeNotConfigured
+	CodeChainIDMismatch
+	CodeInsufficientBalance
+	CodeDecodeFailed
+	CodePostDeleted
+	CodeWrongSigningKey
+	CodeTxNotReplaceable
+	CodeInvalidHex
+	CodePreconditionFailed
+	CodeTxAlreadyPending
+	CodeTxTooLarge
 )
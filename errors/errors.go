@@ -23,6 +23,8 @@ type Error interface {
 	BlockChainLog() string
 	AddCause(cause error) Error
 	Cause() error
+	AddRawData(data []byte) Error
+	RawData() []byte
 }
 
 // NewError creates a new Error
@@ -36,6 +38,7 @@ type serverError struct {
 	blockChainCode uint32
 	blockChainLog  string
 	cause          error
+	rawData        []byte
 }
 
 func newError(code CodeType, msg string) *serverError {
@@ -90,3 +93,14 @@ func (err *serverError) AddCause(cause error) Error {
 func (err *serverError) Cause() error {
 	return err.cause
 }
+
+// AddRawData attaches the raw bytes which caused the error, e.g. undecodable query response.
+func (err *serverError) AddRawData(data []byte) Error {
+	err.rawData = data
+	return err
+}
+
+// RawData returns the raw bytes which caused the error.
+func (err *serverError) RawData() []byte {
+	return err.rawData
+}
@@ -27,6 +27,32 @@ func CodeToDefaultMsg(code CodeType) string {
 		return "Empty Response"
 	case CodeTimeout:
 		return "timeout"
+	case CodeUnknownApp:
+		return "Unknown app"
+	case CodeTxExpired:
+		return "Transaction expired"
+	case CodeNodeNotConfigured:
+		return "Node not configured"
+	case CodeChainIDMismatch:
+		return "Chain ID mismatch"
+	case CodeInsufficientBalance:
+		return "Insufficient balance"
+	case CodeDecodeFailed:
+		return "Failed to decode response"
+	case CodePostDeleted:
+		return "Post is deleted"
+	case CodeWrongSigningKey:
+		return "Signing key doesn't match the account"
+	case CodeTxNotReplaceable:
+		return "Transaction can't be replaced"
+	case CodeInvalidHex:
+		return "Invalid hex string"
+	case CodePreconditionFailed:
+		return "Precondition failed"
+	case CodeTxAlreadyPending:
+		return "Transaction is already pending"
+	case CodeTxTooLarge:
+		return "Transaction is too large"
 	default:
 		return fmt.Sprintf("Unknown code %d", code)
 	}
@@ -141,3 +167,133 @@ func Timeout(msg string) Error {
 func Timeoutf(format string, args ...interface{}) Error {
 	return newError(CodeTimeout, fmt.Sprintf(format, args...))
 }
+
+//UnknownApp creates an error with CodeUnknownApp
+func UnknownApp(msg string) Error {
+	return newError(CodeUnknownApp, msg)
+}
+
+//UnknownAppf creates an error with CodeUnknownApp and formatted message
+func UnknownAppf(format string, args ...interface{}) Error {
+	return newError(CodeUnknownApp, fmt.Sprintf(format, args...))
+}
+
+//TxExpired creates an error with CodeTxExpired
+func TxExpired(msg string) Error {
+	return newError(CodeTxExpired, msg)
+}
+
+//TxExpiredf creates an error with CodeTxExpired and formatted message
+func TxExpiredf(format string, args ...interface{}) Error {
+	return newError(CodeTxExpired, fmt.Sprintf(format, args...))
+}
+
+//NodeNotConfigured creates an error with CodeNodeNotConfigured
+func NodeNotConfigured(msg string) Error {
+	return newError(CodeNodeNotConfigured, msg)
+}
+
+//NodeNotConfiguredf creates an error with CodeNodeNotConfigured and formatted message
+func NodeNotConfiguredf(format string, args ...interface{}) Error {
+	return newError(CodeNodeNotConfigured, fmt.Sprintf(format, args...))
+}
+
+//ChainIDMismatch creates an error with CodeChainIDMismatch
+func ChainIDMismatch(msg string) Error {
+	return newError(CodeChainIDMismatch, msg)
+}
+
+//ChainIDMismatchf creates an error with CodeChainIDMismatch and formatted message
+func ChainIDMismatchf(format string, args ...interface{}) Error {
+	return newError(CodeChainIDMismatch, fmt.Sprintf(format, args...))
+}
+
+//InsufficientBalance creates an error with CodeInsufficientBalance
+func InsufficientBalance(msg string) Error {
+	return newError(CodeInsufficientBalance, msg)
+}
+
+//InsufficientBalancef creates an error with CodeInsufficientBalance and formatted message
+func InsufficientBalancef(format string, args ...interface{}) Error {
+	return newError(CodeInsufficientBalance, fmt.Sprintf(format, args...))
+}
+
+//DecodeFailed creates an error with CodeDecodeFailed
+func DecodeFailed(msg string) Error {
+	return newError(CodeDecodeFailed, msg)
+}
+
+//DecodeFailedf creates an error with CodeDecodeFailed and formatted message
+func DecodeFailedf(format string, args ...interface{}) Error {
+	return newError(CodeDecodeFailed, fmt.Sprintf(format, args...))
+}
+
+//PostDeleted creates an error with CodePostDeleted
+func PostDeleted(msg string) Error {
+	return newError(CodePostDeleted, msg)
+}
+
+//PostDeletedf creates an error with CodePostDeleted and formatted message
+func PostDeletedf(format string, args ...interface{}) Error {
+	return newError(CodePostDeleted, fmt.Sprintf(format, args...))
+}
+
+//WrongSigningKey creates an error with CodeWrongSigningKey
+func WrongSigningKey(msg string) Error {
+	return newError(CodeWrongSigningKey, msg)
+}
+
+//WrongSigningKeyf creates an error with CodeWrongSigningKey and formatted message
+func WrongSigningKeyf(format string, args ...interface{}) Error {
+	return newError(CodeWrongSigningKey, fmt.Sprintf(format, args...))
+}
+
+//TxNotReplaceable creates an error with CodeTxNotReplaceable
+func TxNotReplaceable(msg string) Error {
+	return newError(CodeTxNotReplaceable, msg)
+}
+
+//TxNotReplaceablef creates an error with CodeTxNotReplaceable and formatted message
+func TxNotReplaceablef(format string, args ...interface{}) Error {
+	return newError(CodeTxNotReplaceable, fmt.Sprintf(format, args...))
+}
+
+//InvalidHex creates an error with CodeInvalidHex
+func InvalidHex(msg string) Error {
+	return newError(CodeInvalidHex, msg)
+}
+
+//InvalidHexf creates an error with CodeInvalidHex and formatted message
+func InvalidHexf(format string, args ...interface{}) Error {
+	return newError(CodeInvalidHex, fmt.Sprintf(format, args...))
+}
+
+//PreconditionFailed creates an error with CodePreconditionFailed
+func PreconditionFailed(msg string) Error {
+	return newError(CodePreconditionFailed, msg)
+}
+
+//PreconditionFailedf creates an error with CodePreconditionFailed and formatted message
+func PreconditionFailedf(format string, args ...interface{}) Error {
+	return newError(CodePreconditionFailed, fmt.Sprintf(format, args...))
+}
+
+//TxAlreadyPending creates an error with CodeTxAlreadyPending
+func TxAlreadyPending(msg string) Error {
+	return newError(CodeTxAlreadyPending, msg)
+}
+
+//TxAlreadyPendingf creates an error with CodeTxAlreadyPending and formatted message
+func TxAlreadyPendingf(format string, args ...interface{}) Error {
+	return newError(CodeTxAlreadyPending, fmt.Sprintf(format, args...))
+}
+
+//TxTooLarge creates an error with CodeTxTooLarge
+func TxTooLarge(msg string) Error {
+	return newError(CodeTxTooLarge, msg)
+}
+
+//TxTooLargef creates an error with CodeTxTooLarge and formatted message
+func TxTooLargef(format string, args ...interface{}) Error {
+	return newError(CodeTxTooLarge, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,80 @@
+package errors
+
+// bcCodeToHumanMsg holds the messages for end users of the common blockchain codes.
+var bcCodeToHumanMsg = map[BCCodeType]string{
+	CodeInvalidUsername:              "The username is invalid.",
+	CodeAccountNotFound:              "The account doesn't exist.",
+	CodeInsufficientDeposit:          "The deposit is not enough.",
+	CodeInvalidCoin:                  "The amount is invalid.",
+	CodePostNotFound:                 "The post doesn't exist.",
+	CodeDeveloperNotFound:            "The app is not a registered developer.",
+	CodeNoSignatures:                 "The transaction is not signed.",
+	CodeUnknownMsgType:               "The blockchain doesn't support this kind of transaction.",
+	CodeWrongNumberOfSigners:         "The transaction has a wrong number of signatures.",
+	CodeInvalidSequence:              "The transaction is out of order, please try again.",
+	CodeUnverifiedBytes:              "The signature of the transaction is invalid.",
+	CodeReceiverNotFound:             "The receiver doesn't exist.",
+	CodeSenderNotFound:               "The sender doesn't exist.",
+	CodeReferrerNotFound:             "The referrer doesn't exist.",
+	CodeInvalidMemo:                  "The memo is invalid.",
+	CodeCheckResetKey:                "The transaction must be signed with the reset key.",
+	CodeCheckTransactionKey:          "The transaction must be signed with the transaction key.",
+	CodeCheckGrantAppKey:             "The app is not authorized to sign this transaction.",
+	CodeCheckAuthenticatePubKeyOwner: "The signing key doesn't belong to the account.",
+	CodeGrantKeyExpired:              "The authorization of the app has expired.",
+	CodeGrantKeyNoLeftTimes:          "The authorization of the app has been used up.",
+	CodeGrantKeyMismatch:             "The app is not authorized to sign this transaction.",
+	CodeAppGrantKeyMismatch:          "The app is not authorized to sign this transaction.",
+	CodeAccountTPSCapacityNotEnough:  "The account has sent too many transactions, please wait a moment.",
+	CodeAccountSavingCoinNotEnough:   "The balance is not enough.",
+	CodeAccountAlreadyExists:         "The username is already taken.",
+	CodeRegisterFeeInsufficient:      "The register fee is not enough.",
+	CodePostAlreadyExist:             "The post already exists.",
+	CodeDonatePostIsDeleted:          "The post has been deleted.",
+	CodeUpdatePostIsDeleted:          "The post has been deleted.",
+	CodeReportOrUpvoteTooOften:       "Reports and upvotes are too frequent, please wait a moment.",
+	CodeReportOrUpvoteAlreadyExist:   "The post has already been reported or upvoted.",
+	CodePostTooOften:                 "Posts are too frequent, please wait a moment.",
+	CodeVoteAlreadyExist:             "The vote has already been cast.",
+	CodeDeveloperAlreadyExist:        "The app is already a registered developer.",
+	CodeInsufficientDeveloperDeposit: "The developer deposit is not enough.",
+	CodeGrantPermissionTooHigh:       "The permission is too high to be granted.",
+}
+
+// codeToHumanMsg holds the messages for end users of the codes of this library.
+var codeToHumanMsg = map[CodeType]string{
+	CodeInvalidSequenceNumber: "The transaction is out of order, please try again.",
+	CodeTimeout:               "The request timed out, please try again.",
+	CodeInsufficientBalance:   "The balance is not enough.",
+	CodePostDeleted:           "The post has been deleted.",
+	CodeWrongSigningKey:       "The signing key doesn't belong to the account.",
+	CodeTxAlreadyPending:      "The transaction is already being processed.",
+	CodeTxTooLarge:            "The transaction is too large.",
+}
+
+// HumanMessage returns a message describing err for end users. The blockchain
+// code attached by AddBlockChainCode is translated first, then the code of err.
+// An unknown blockchain code falls back to the blockchain log, any other error
+// to its Error().
+func HumanMessage(err error) string {
+	if err == nil {
+		return ""
+	}
+	vErr, ok := err.(Error)
+	if !ok {
+		return err.Error()
+	}
+	if bcCode := vErr.BlockChainCode(); bcCode != 0 {
+		// the codespace is in the upper bits of the ABCI code
+		if msg, ok := bcCodeToHumanMsg[BCCodeType(bcCode&0xffff)]; ok {
+			return msg
+		}
+		if vErr.BlockChainLog() != "" {
+			return vErr.BlockChainLog()
+		}
+	}
+	if msg, ok := codeToHumanMsg[vErr.CodeType()]; ok {
+		return msg
+	}
+	return err.Error()
+}
@@ -0,0 +1,52 @@
+package errors
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestHumanMessage(t *testing.T) {
+	abciCode := func(code BCCodeType) uint32 {
+		return uint32(LinoErrorCodeSpace)<<16 | uint32(code)
+	}
+
+	testCases := map[string]struct {
+		err    error
+		expect string
+	}{
+		"known blockchain code": {
+			err:    CheckTxFail("CheckTx failed!").AddBlockChainCode(abciCode(CodeAccountSavingCoinNotEnough)).AddBlockChainLog("raw log"),
+			expect: "The balance is not enough.",
+		},
+		"invalid sequence": {
+			err:    InvalidSequenceNumber("invalid seq").AddBlockChainCode(abciCode(CodeInvalidSequence)),
+			expect: "The transaction is out of order, please try again.",
+		},
+		"unknown blockchain code falls back to the log": {
+			err:    DeliverTxFail("DeliverTx failed!").AddBlockChainCode(abciCode(CodeGenesisFailed)).AddBlockChainLog("raw log"),
+			expect: "raw log",
+		},
+		"known code of the library": {
+			err:    Timeout("query timeout"),
+			expect: "The request timed out, please try again.",
+		},
+		"unknown code of the library": {
+			err:    QueryFail("query failed"),
+			expect: QueryFail("query failed").Error(),
+		},
+		"untyped error": {
+			err:    fmt.Errorf("untyped"),
+			expect: "untyped",
+		},
+		"nil": {
+			err:    nil,
+			expect: "",
+		},
+	}
+
+	for testName, tc := range testCases {
+		if got := HumanMessage(tc.err); got != tc.expect {
+			t.Errorf("%s: diff message, got %q, want %q", testName, got, tc.expect)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package model
+
+import (
+	"encoding/hex"
+	"strings"
+
+	"github.com/lino-network/lino-go/errors"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+	cmn "github.com/tendermint/tendermint/libs/common"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+)
+
+// ParseBroadcastResult converts the result returned by the node for a sync
+// or commit broadcast into a BroadcastResponse, with the height of the block
+// for a commit broadcast. A non-zero CheckTx or
+// DeliverTx code is returned as a typed error carrying the blockchain code and log.
+func ParseBroadcastResult(res interface{}) (*BroadcastResponse, error) {
+	switch res := res.(type) {
+	case *ctypes.ResultBroadcastTx:
+		if err := checkTxCode(res.Code, res.Log); err != nil {
+			return nil, err
+		}
+		return newBroadcastResponse(res.Hash), nil
+	case *ctypes.ResultBroadcastTxCommit:
+		if err := checkTxCode(res.CheckTx.Code, res.CheckTx.Log); err != nil {
+			return nil, err
+		}
+		if res.DeliverTx.Code != uint32(0) {
+			return nil, errors.DeliverTxFail("DeliverTx failed!").AddBlockChainCode(res.DeliverTx.Code).AddBlockChainLog(res.DeliverTx.Log)
+		}
+		resp := newBroadcastResponse(res.Hash)
+		resp.Height = res.Height
+		return resp, nil
+	default:
+		return nil, errors.FailedToBroadcast("error to parse the broadcast response")
+	}
+}
+
+// NewTxResult converts the DeliverTx response of a transaction into a TxResult
+// with decoded events.
+func NewTxResult(deliverTx abci.ResponseDeliverTx) *TxResult {
+	return &TxResult{
+		Code:      deliverTx.Code,
+		Log:       deliverTx.Log,
+		Info:      deliverTx.Info,
+		GasWanted: deliverTx.GasWanted,
+		GasUsed:   deliverTx.GasUsed,
+		Events:    NewTags(deliverTx.Tags),
+	}
+}
+
+// NewTags decodes the key-value pairs of an ABCI response.
+func NewTags(pairs []cmn.KVPair) []Tag {
+	tags := make([]Tag, 0, len(pairs))
+	for _, pair := range pairs {
+		tags = append(tags, Tag{Key: string(pair.Key), Value: string(pair.Value)})
+	}
+	return tags
+}
+
+// RetrieveCodeFromBlockChainCode strips the codespace from an ABCI code
+// and returns the Lino error code, see errors.BCCodeType.
+func RetrieveCodeFromBlockChainCode(bcCode uint32) uint32 {
+	return bcCode & 0xffff
+}
+
+func checkTxCode(code uint32, log string) error {
+	if code == uint32(0) {
+		return nil
+	}
+	if RetrieveCodeFromBlockChainCode(code) == InvalidSeqErrCode {
+		return errors.InvalidSequenceNumber("invalid seq").AddBlockChainCode(code).AddBlockChainLog(log)
+	}
+	return errors.CheckTxFail("CheckTx failed!").AddBlockChainCode(code).AddBlockChainLog(log)
+}
+
+func newBroadcastResponse(hash []byte) *BroadcastResponse {
+	return &BroadcastResponse{
+		CommitHash: strings.ToUpper(hex.EncodeToString(hash)),
+	}
+}
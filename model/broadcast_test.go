@@ -0,0 +1,121 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/lino-network/lino-go/errors"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+	cmn "github.com/tendermint/tendermint/libs/common"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+)
+
+func TestParseBroadcastResult(t *testing.T) {
+	hash := []byte{0xab, 0xcd, 0x01}
+	seqCode := uint32(errors.LinoErrorCodeSpace<<16 | InvalidSeqErrCode)
+	// 410 shares its low byte with the invalid sequence code.
+	otherCode := uint32(errors.LinoErrorCodeSpace<<16 | 410)
+
+	testCases := map[string]struct {
+		res            interface{}
+		expectHash     string
+		expectHeight   int64
+		expectCodeType errors.CodeType
+		expectBCCode   uint32
+	}{
+		"sync success": {
+			res:        &ctypes.ResultBroadcastTx{Hash: hash},
+			expectHash: "ABCD01",
+		},
+		"sync invalid sequence": {
+			res:            &ctypes.ResultBroadcastTx{Code: seqCode, Hash: hash},
+			expectCodeType: errors.CodeInvalidSequenceNumber,
+			expectBCCode:   seqCode,
+		},
+		"sync check tx fail": {
+			res:            &ctypes.ResultBroadcastTx{Code: otherCode, Hash: hash},
+			expectCodeType: errors.CodeCheckTxFail,
+			expectBCCode:   otherCode,
+		},
+		"commit success": {
+			res:          &ctypes.ResultBroadcastTxCommit{Hash: hash, Height: 10},
+			expectHash:   "ABCD01",
+			expectHeight: 10,
+		},
+		"commit invalid sequence": {
+			res: &ctypes.ResultBroadcastTxCommit{
+				CheckTx: abci.ResponseCheckTx{Code: seqCode},
+				Hash:    hash,
+			},
+			expectCodeType: errors.CodeInvalidSequenceNumber,
+			expectBCCode:   seqCode,
+		},
+		"commit check tx fail": {
+			res: &ctypes.ResultBroadcastTxCommit{
+				CheckTx: abci.ResponseCheckTx{Code: otherCode},
+				Hash:    hash,
+			},
+			expectCodeType: errors.CodeCheckTxFail,
+			expectBCCode:   otherCode,
+		},
+		"commit deliver tx fail": {
+			res: &ctypes.ResultBroadcastTxCommit{
+				DeliverTx: abci.ResponseDeliverTx{Code: otherCode},
+				Hash:      hash,
+			},
+			expectCodeType: errors.CodeDeliverTxFail,
+			expectBCCode:   otherCode,
+		},
+		"unknown result": {
+			res:            "unknown",
+			expectCodeType: errors.CodeFailedToBroadcast,
+		},
+	}
+
+	for testName, tc := range testCases {
+		resp, err := ParseBroadcastResult(tc.res)
+		if tc.expectCodeType == errors.CodeOK {
+			if err != nil {
+				t.Errorf("%s: failed to parse result, got err %v", testName, err)
+				continue
+			}
+			if resp.CommitHash != tc.expectHash {
+				t.Errorf("%s: diff commit hash, got %v, want %v", testName, resp.CommitHash, tc.expectHash)
+			}
+			if resp.Height != tc.expectHeight {
+				t.Errorf("%s: diff height, got %v, want %v", testName, resp.Height, tc.expectHeight)
+			}
+			continue
+		}
+
+		vErr, ok := err.(errors.Error)
+		if !ok {
+			t.Errorf("%s: expect typed error, got %v", testName, err)
+			continue
+		}
+		if vErr.CodeType() != tc.expectCodeType {
+			t.Errorf("%s: diff code type, got %v, want %v", testName, vErr.CodeType(), tc.expectCodeType)
+		}
+		if vErr.BlockChainCode() != tc.expectBCCode {
+			t.Errorf("%s: diff blockchain code, got %v, want %v", testName, vErr.BlockChainCode(), tc.expectBCCode)
+		}
+	}
+}
+
+func TestNewTxResult(t *testing.T) {
+	deliverTx := abci.ResponseDeliverTx{
+		Log:       "ok",
+		GasWanted: 200,
+		GasUsed:   100,
+		Tags: []cmn.KVPair{
+			{Key: []byte("permlink"), Value: []byte("user#1")},
+		},
+	}
+	result := NewTxResult(deliverTx)
+	if result.GasWanted != 200 || result.GasUsed != 100 {
+		t.Errorf("diff gas, got wanted %v used %v, want 200 and 100", result.GasWanted, result.GasUsed)
+	}
+	if len(result.Events) != 1 || result.Events[0] != (Tag{Key: "permlink", Value: "user#1"}) {
+		t.Errorf("diff events, got %v", result.Events)
+	}
+}
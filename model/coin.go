@@ -1,18 +1,58 @@
 package model
 
 import (
+	"math"
 	"math/big"
 	"strings"
+
+	"github.com/lino-network/lino-go/errors"
+)
+
+// Decimals is the number of coins in one LNO.
+const Decimals = 100000
+
+// Bounds of a LNO amount in string format, same as on Lino blockchain.
+var (
+	LowerBoundRat = big.NewRat(1, Decimals)
+	UpperBoundRat = big.NewRat(math.MaxInt64/Decimals, 1)
 )
 
+// BaseDenom is the denom of Lino blockchain, used when a coin carries no denom.
+const BaseDenom = "LNO"
+
 // Coin is the same struct used in Lino blockchain.
+// Denom is empty for coins of the base denom, which keeps the JSON
+// and the sign bytes of such coins the same as on Lino blockchain.
 type Coin struct {
-	Amount Int `json:"amount"`
+	Amount Int    `json:"amount"`
+	Denom  string `json:"denom,omitempty"`
+}
+
+// NewCoinFromInt64 returns a coin with amount.
+func NewCoinFromInt64(amount int64) Coin {
+	return Coin{
+		Amount: Int{big.NewInt(amount)},
+	}
+}
+
+// NewCoinFromBigInt returns a coin with amount.
+func NewCoinFromBigInt(amount *big.Int) Coin {
+	return Coin{
+		Amount: Int{amount},
+	}
 }
 
 func NewCoinFromString(amount string) (Coin, bool) {
 	res, ok := NewIntFromString(amount)
-	return Coin{res}, ok
+	return Coin{Amount: res}, ok
+}
+
+// GetDenom returns the denom of the coin, BaseDenom if none is set.
+func (coin Coin) GetDenom() string {
+	if coin.Denom == "" {
+		return BaseDenom
+	}
+	return coin.Denom
 }
 
 func (c Coin) CoinToLNO() string {
@@ -75,16 +115,42 @@ func (coin Coin) IsNotNegative() bool {
 	return coin.Amount.Sign() >= 0
 }
 
-// Adds amounts of two coins with same denom
+// SameDenom returns true if the two coins have the same denom,
+// an empty denom is the same as BaseDenom.
+func (coin Coin) SameDenom(other Coin) bool {
+	return coin.GetDenom() == other.GetDenom()
+}
+
+// Adds amounts of two coins with same denom. The denom of coinB is not
+// checked, the result keeps the denom of the receiver, use CheckedPlus
+// if the coins may have different denoms.
 func (coin Coin) Plus(coinB Coin) Coin {
 	r := coin.Amount.Add(coinB.Amount)
-	return Coin{r}
+	return Coin{Amount: r, Denom: coin.Denom}
 }
 
-// Subtracts amounts of two coins with same denom
+// Subtracts amounts of two coins with same denom. The denom of coinB is not
+// checked, the result keeps the denom of the receiver, use CheckedMinus
+// if the coins may have different denoms.
 func (coin Coin) Minus(coinB Coin) Coin {
 	r := coin.Amount.Sub(coinB.Amount)
-	return Coin{r}
+	return Coin{Amount: r, Denom: coin.Denom}
+}
+
+// CheckedPlus adds amounts of two coins, it fails if their denoms differ.
+func (coin Coin) CheckedPlus(coinB Coin) (Coin, error) {
+	if !coin.SameDenom(coinB) {
+		return Coin{}, errors.InvalidArgf("can't add %v to %v", coinB.GetDenom(), coin.GetDenom())
+	}
+	return coin.Plus(coinB), nil
+}
+
+// CheckedMinus subtracts amounts of two coins, it fails if their denoms differ.
+func (coin Coin) CheckedMinus(coinB Coin) (Coin, error) {
+	if !coin.SameDenom(coinB) {
+		return Coin{}, errors.InvalidArgf("can't subtract %v from %v", coinB.GetDenom(), coin.GetDenom())
+	}
+	return coin.Minus(coinB), nil
 }
 
 // SDKCoin is the same struct used in cosmos-sdk.
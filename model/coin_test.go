@@ -1,23 +1,14 @@
 package model
 
 import (
+	"encoding/json"
 	"errors"
-	"math"
 	"math/big"
 	"testing"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
 )
 
-var (
-	LowerBoundRat = big.NewRat(1, Decimals)
-	UpperBoundRat = big.NewRat(math.MaxInt64/Decimals, 1)
-)
-
-const (
-	Decimals = 100000
-)
-
 func TestCoinToLNO(t *testing.T) {
 	testCases := map[string]struct {
 		inputLino     string
@@ -72,18 +63,6 @@ func TestCoinToLNO(t *testing.T) {
 // helper function
 //
 
-func NewCoinFromInt64(amount int64) Coin {
-	return Coin{
-		Amount: Int{big.NewInt(amount)},
-	}
-}
-
-func NewCoinFromBigInt(amount *big.Int) Coin {
-	return Coin{
-		Amount: Int{amount},
-	}
-}
-
 func LinoToCoin(lino string) (Coin, error) {
 	num, success := new(big.Rat).SetString(lino)
 	if !success {
@@ -101,3 +80,131 @@ func LinoToCoin(lino string) (Coin, error) {
 func RatToCoin(rat sdk.Rat) Coin {
 	return NewCoinFromBigInt(rat.EvaluateBig())
 }
+
+func TestCoinUnmarshalJSON(t *testing.T) {
+	testCases := map[string]struct {
+		input        string
+		expectAmount string
+		expectDenom  string
+		expectErr    bool
+	}{
+		"string amount": {
+			input:        `{"amount":"123"}`,
+			expectAmount: "123",
+			expectDenom:  BaseDenom,
+		},
+		"amount with denom": {
+			input:        `{"amount":"123","denom":"stake"}`,
+			expectAmount: "123",
+			expectDenom:  "stake",
+		},
+		"number amount": {
+			input:        `{"amount":123}`,
+			expectAmount: "123",
+		},
+		"number amount larger than int64": {
+			input:        `{"amount":123456789012345678901234567890}`,
+			expectAmount: "123456789012345678901234567890",
+		},
+		"negative string amount": {
+			input:        `{"amount":"-5"}`,
+			expectAmount: "-5",
+		},
+		"fractional number amount": {
+			input:     `{"amount":1.5}`,
+			expectErr: true,
+		},
+		"invalid string amount": {
+			input:     `{"amount":"abc"}`,
+			expectErr: true,
+		},
+		"bool amount": {
+			input:     `{"amount":true}`,
+			expectErr: true,
+		},
+	}
+
+	for testName, tc := range testCases {
+		var coin Coin
+		err := json.Unmarshal([]byte(tc.input), &coin)
+		if tc.expectErr {
+			if err == nil {
+				t.Errorf("%s: expect error, got coin %v", testName, coin.Amount.String())
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: failed to unmarshal coin, got err %v", testName, err)
+			continue
+		}
+		if coin.Amount.String() != tc.expectAmount {
+			t.Errorf("%s: diff amount, got %v, want %v", testName, coin.Amount.String(), tc.expectAmount)
+		}
+		if tc.expectDenom != "" && coin.GetDenom() != tc.expectDenom {
+			t.Errorf("%s: diff denom, got %v, want %v", testName, coin.GetDenom(), tc.expectDenom)
+		}
+	}
+}
+
+func TestCoinCheckedPlusMinus(t *testing.T) {
+	testCases := map[string]struct {
+		coinA       Coin
+		coinB       Coin
+		expectPlus  string
+		expectMinus string
+		expectDenom string
+		expectErr   bool
+	}{
+		"base denom": {
+			coinA:       NewCoinFromInt64(5),
+			coinB:       NewCoinFromInt64(3),
+			expectPlus:  "8",
+			expectMinus: "2",
+			expectDenom: BaseDenom,
+		},
+		"empty denom and base denom": {
+			coinA:       NewCoinFromInt64(5),
+			coinB:       Coin{Amount: NewCoinFromInt64(3).Amount, Denom: BaseDenom},
+			expectPlus:  "8",
+			expectMinus: "2",
+			expectDenom: BaseDenom,
+		},
+		"same denom": {
+			coinA:       Coin{Amount: NewCoinFromInt64(5).Amount, Denom: "stake"},
+			coinB:       Coin{Amount: NewCoinFromInt64(3).Amount, Denom: "stake"},
+			expectPlus:  "8",
+			expectMinus: "2",
+			expectDenom: "stake",
+		},
+		"different denom": {
+			coinA:     NewCoinFromInt64(5),
+			coinB:     Coin{Amount: NewCoinFromInt64(3).Amount, Denom: "stake"},
+			expectErr: true,
+		},
+	}
+
+	for testName, tc := range testCases {
+		plus, plusErr := tc.coinA.CheckedPlus(tc.coinB)
+		minus, minusErr := tc.coinA.CheckedMinus(tc.coinB)
+		if tc.expectErr {
+			if plusErr == nil || minusErr == nil {
+				t.Errorf("%s: expect error, got plus err %v, minus err %v", testName, plusErr, minusErr)
+			}
+			// the unchecked variants keep the denom of the receiver
+			if tc.coinA.Plus(tc.coinB).Denom != tc.coinA.Denom || tc.coinA.Minus(tc.coinB).Denom != tc.coinA.Denom {
+				t.Errorf("%s: unchecked result doesn't keep the denom of %v", testName, tc.coinA.GetDenom())
+			}
+			continue
+		}
+		if plusErr != nil || minusErr != nil {
+			t.Errorf("%s: got plus err %v, minus err %v", testName, plusErr, minusErr)
+			continue
+		}
+		if plus.Amount.String() != tc.expectPlus || plus.GetDenom() != tc.expectDenom {
+			t.Errorf("%s: diff plus, got %v %v, want %v %v", testName, plus.Amount.String(), plus.GetDenom(), tc.expectPlus, tc.expectDenom)
+		}
+		if minus.Amount.String() != tc.expectMinus || minus.GetDenom() != tc.expectDenom {
+			t.Errorf("%s: diff minus, got %v %v, want %v %v", testName, minus.Amount.String(), minus.GetDenom(), tc.expectMinus, tc.expectDenom)
+		}
+	}
+}
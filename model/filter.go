@@ -0,0 +1,52 @@
+package model
+
+import "strings"
+
+// PostFilter selects posts returned by a query. The zero value matches all posts.
+type PostFilter struct {
+	// ExcludeComments leaves out posts that reply to another post.
+	ExcludeComments bool
+	// ExcludeDeleted leaves out deleted posts.
+	ExcludeDeleted bool
+	// Contains, if set, leaves out posts whose title and content don't
+	// contain it, ignoring case.
+	Contains string
+	// CreatedSince, if positive, leaves out posts created before it, in unix
+	// seconds as Post.CreatedAt.
+	CreatedSince int64
+}
+
+// Match returns true if post passes the filter.
+func (filter PostFilter) Match(post *Post) bool {
+	if filter.ExcludeComments && post.ParentAuthor != "" {
+		return false
+	}
+	if filter.ExcludeDeleted && post.IsDeleted {
+		return false
+	}
+	if !filter.matchText(post.Title, post.Content) {
+		return false
+	}
+	if filter.CreatedSince > 0 && post.CreatedAt < filter.CreatedSince {
+		return false
+	}
+	return true
+}
+
+// MatchInfo returns true if a post with info may pass the filter, i.e. the
+// conditions known from the info hold. The rest are checked by Match.
+func (filter PostFilter) MatchInfo(info *PostInfo) bool {
+	if filter.ExcludeComments && info.ParentAuthor != "" {
+		return false
+	}
+	return filter.matchText(info.Title, info.Content)
+}
+
+func (filter PostFilter) matchText(title, content string) bool {
+	if filter.Contains == "" {
+		return true
+	}
+	substr := strings.ToLower(filter.Contains)
+	return strings.Contains(strings.ToLower(title), substr) ||
+		strings.Contains(strings.ToLower(content), substr)
+}
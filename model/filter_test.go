@@ -0,0 +1,126 @@
+package model
+
+import "testing"
+
+func TestPostFilterMatch(t *testing.T) {
+	post := &Post{Author: "user1", PostID: "post1"}
+	comment := &Post{Author: "user1", PostID: "comment1", ParentAuthor: "user2", ParentPostID: "post2"}
+	deleted := &Post{Author: "user1", PostID: "post2", IsDeleted: true}
+	titled := &Post{Author: "user1", PostID: "post3", Title: "Hello World", Content: "first post"}
+
+	testCases := map[string]struct {
+		filter       PostFilter
+		post         *Post
+		expectResult bool
+	}{
+		"zero filter matches post": {
+			filter:       PostFilter{},
+			post:         post,
+			expectResult: true,
+		},
+		"zero filter matches comment": {
+			filter:       PostFilter{},
+			post:         comment,
+			expectResult: true,
+		},
+		"zero filter matches deleted post": {
+			filter:       PostFilter{},
+			post:         deleted,
+			expectResult: true,
+		},
+		"exclude comments": {
+			filter:       PostFilter{ExcludeComments: true},
+			post:         comment,
+			expectResult: false,
+		},
+		"exclude comments keeps post": {
+			filter:       PostFilter{ExcludeComments: true},
+			post:         post,
+			expectResult: true,
+		},
+		"exclude deleted": {
+			filter:       PostFilter{ExcludeDeleted: true},
+			post:         deleted,
+			expectResult: false,
+		},
+		"exclude deleted keeps comment": {
+			filter:       PostFilter{ExcludeDeleted: true},
+			post:         comment,
+			expectResult: true,
+		},
+		"contains in title ignoring case": {
+			filter:       PostFilter{Contains: "hello"},
+			post:         titled,
+			expectResult: true,
+		},
+		"contains in content": {
+			filter:       PostFilter{Contains: "FIRST"},
+			post:         titled,
+			expectResult: true,
+		},
+		"contains not found": {
+			filter:       PostFilter{Contains: "bye"},
+			post:         titled,
+			expectResult: false,
+		},
+		"created since": {
+			filter:       PostFilter{CreatedSince: 100},
+			post:         &Post{Author: "user1", PostID: "post4", CreatedAt: 100},
+			expectResult: true,
+		},
+		"created before": {
+			filter:       PostFilter{CreatedSince: 100},
+			post:         &Post{Author: "user1", PostID: "post4", CreatedAt: 99},
+			expectResult: false,
+		},
+	}
+
+	for testName, tc := range testCases {
+		if got := tc.filter.Match(tc.post); got != tc.expectResult {
+			t.Errorf("%s: diff result, got %v, want %v", testName, got, tc.expectResult)
+		}
+	}
+}
+
+func TestPostFilterMatchInfo(t *testing.T) {
+	info := &PostInfo{Author: "user1", PostID: "post1", Title: "Hello", Content: "World"}
+	comment := &PostInfo{Author: "user1", PostID: "comment1", ParentAuthor: "user2", ParentPostID: "post2"}
+
+	testCases := map[string]struct {
+		filter       PostFilter
+		info         *PostInfo
+		expectResult bool
+	}{
+		"zero filter": {
+			filter:       PostFilter{},
+			info:         comment,
+			expectResult: true,
+		},
+		"exclude comments": {
+			filter:       PostFilter{ExcludeComments: true},
+			info:         comment,
+			expectResult: false,
+		},
+		"exclude deleted is checked on the meta": {
+			filter:       PostFilter{ExcludeDeleted: true},
+			info:         info,
+			expectResult: true,
+		},
+		"contains": {
+			filter:       PostFilter{Contains: "world"},
+			info:         info,
+			expectResult: true,
+		},
+		"contains not found": {
+			filter:       PostFilter{Contains: "bye"},
+			info:         info,
+			expectResult: false,
+		},
+	}
+
+	for testName, tc := range testCases {
+		if got := tc.filter.MatchInfo(tc.info); got != tc.expectResult {
+			t.Errorf("%s: diff result, got %v, want %v", testName, got, tc.expectResult)
+		}
+	}
+}
@@ -161,14 +161,18 @@ func marshalJSON(i *big.Int) ([]byte, error) {
 }
 
 // UnmarshalJSON for custom decoding scheme
-// Must be encoded as a string for JSON precision
+// Accepts both a string and a number, some endpoints emit the amount as a JSON number
 func unmarshalJSON(i *big.Int, bz []byte) error {
 	var text string
-	err := json.Unmarshal(bz, &text)
-	if err != nil {
+	if err := json.Unmarshal(bz, &text); err == nil {
+		return i.UnmarshalText([]byte(text))
+	}
+
+	var number json.Number
+	if err := json.Unmarshal(bz, &number); err != nil {
 		return err
 	}
-	return i.UnmarshalText([]byte(text))
+	return i.UnmarshalText([]byte(number.String()))
 }
 
 // MarshalAmino defines custom encoding scheme
@@ -3,13 +3,65 @@
 package model
 
 import (
+	"fmt"
+	"strings"
+
+	"github.com/lino-network/lino-go/errors"
+
 	crypto "github.com/tendermint/tendermint/crypto"
 )
 
-type Msg interface{}
+// Msg is a message which can be included in a transaction.
+type Msg interface {
+	// Type returns the name of the module on Lino blockchain that handles the message.
+	Type() string
+	// ValidateBasic checks the message locally with the same rules as on Lino blockchain.
+	ValidateBasic() error
+}
+
+// GetSigner returns the username whose key signs msg,
+// or false if msg does not report its signer.
+func GetSigner(msg Msg) (string, bool) {
+	signed, ok := msg.(interface{ GetSigner() string })
+	if !ok {
+		return "", false
+	}
+	return signed.GetSigner(), true
+}
+
+// ValidateMsgs runs ValidateBasic on every message of a batch without any
+// network call. It returns an InvalidArg error listing each invalid message
+// by its index and reason, or nil if all of them are valid.
+func ValidateMsgs(msgs []Msg) error {
+	var reasons []string
+	for i, msg := range msgs {
+		if msg == nil {
+			reasons = append(reasons, fmt.Sprintf("msg %v: nil", i))
+			continue
+		}
+		if err := msg.ValidateBasic(); err != nil {
+			reasons = append(reasons, fmt.Sprintf("msg %v (%T): %v", i, msg, err))
+		}
+	}
+	if len(reasons) == 0 {
+		return nil
+	}
+	return errors.InvalidArgf("%v of %v msgs are invalid: %v", len(reasons), len(msgs), strings.Join(reasons, "; "))
+}
 
 type Tx interface{}
 
+// Names of the modules on Lino blockchain that handle messages.
+const (
+	AccountRoute   = "account"
+	PostRoute      = "post"
+	ValidatorRoute = "validator"
+	VoteRoute      = "vote"
+	DeveloperRoute = "developer"
+	InfraRoute     = "infra"
+	ProposalRoute  = "proposal"
+)
+
 //
 // Account related messages
 //
@@ -22,20 +74,44 @@ type RegisterMsg struct {
 	NewAppPubKey         crypto.PubKey `json:"new_app_public_key"`
 }
 
+// Type implements Msg.
+func (msg RegisterMsg) Type() string { return AccountRoute }
+
+// GetSigner returns the username whose key signs the message.
+func (msg RegisterMsg) GetSigner() string { return msg.Referrer }
+
 type FollowMsg struct {
 	Follower string `json:"follower"`
 	Followee string `json:"followee"`
 }
 
+// Type implements Msg.
+func (msg FollowMsg) Type() string { return AccountRoute }
+
+// GetSigner returns the username whose key signs the message.
+func (msg FollowMsg) GetSigner() string { return msg.Follower }
+
 type UnfollowMsg struct {
 	Follower string `json:"follower"`
 	Followee string `json:"followee"`
 }
 
+// Type implements Msg.
+func (msg UnfollowMsg) Type() string { return AccountRoute }
+
+// GetSigner returns the username whose key signs the message.
+func (msg UnfollowMsg) GetSigner() string { return msg.Follower }
+
 type ClaimMsg struct {
 	Username string `json:"username"`
 }
 
+// Type implements Msg.
+func (msg ClaimMsg) Type() string { return AccountRoute }
+
+// GetSigner returns the username whose key signs the message.
+func (msg ClaimMsg) GetSigner() string { return msg.Username }
+
 type RecoverMsg struct {
 	Username             string        `json:"username"`
 	NewResetPubKey       crypto.PubKey `json:"new_reset_public_key"`
@@ -43,6 +119,12 @@ type RecoverMsg struct {
 	NewAppPubKey         crypto.PubKey `json:"new_app_public_key"`
 }
 
+// Type implements Msg.
+func (msg RecoverMsg) Type() string { return AccountRoute }
+
+// GetSigner returns the username whose key signs the message.
+func (msg RecoverMsg) GetSigner() string { return msg.Username }
+
 type TransferMsg struct {
 	Sender   string `json:"sender"`
 	Receiver string `json:"receiver"`
@@ -50,11 +132,31 @@ type TransferMsg struct {
 	Memo     string `json:"memo"`
 }
 
+// Type implements Msg.
+func (msg TransferMsg) Type() string { return AccountRoute }
+
+// GetSigner returns the username whose key signs the message.
+func (msg TransferMsg) GetSigner() string { return msg.Sender }
+
+// Output is a recipient of a multi-send with the amount it receives.
+// Exactly one of Username and Address, the hex address of a key, is set.
+type Output struct {
+	Username string `json:"username"`
+	Address  string `json:"address"`
+	Amount   string `json:"amount"`
+}
+
 type UpdateAccountMsg struct {
 	Username string `json:"username"`
 	JSONMeta string `json:"json_meta"`
 }
 
+// Type implements Msg.
+func (msg UpdateAccountMsg) Type() string { return AccountRoute }
+
+// GetSigner returns the username whose key signs the message.
+func (msg UpdateAccountMsg) GetSigner() string { return msg.Username }
+
 //
 // Post related messages
 //
@@ -71,6 +173,12 @@ type CreatePostMsg struct {
 	RedistributionSplitRate string           `json:"redistribution_split_rate"`
 }
 
+// Type implements Msg.
+func (msg CreatePostMsg) Type() string { return PostRoute }
+
+// GetSigner returns the username whose key signs the message.
+func (msg CreatePostMsg) GetSigner() string { return msg.Author }
+
 type IDToURLMapping struct {
 	Identifier string `json:"identifier"`
 	URL        string `json:"url"`
@@ -84,11 +192,32 @@ type UpdatePostMsg struct {
 	Links   []IDToURLMapping `json:"links"`
 }
 
+// Type implements Msg.
+func (msg UpdatePostMsg) Type() string { return PostRoute }
+
+// GetSigner returns the username whose key signs the message.
+func (msg UpdatePostMsg) GetSigner() string { return msg.Author }
+
+// PostUpdate holds the fields to change by a partial post update.
+// A nil field leaves the current value of the post unchanged.
+// A non-nil empty Links removes all links of the post.
+type PostUpdate struct {
+	Title   *string
+	Content *string
+	Links   []IDToURLMapping
+}
+
 type DeletePostMsg struct {
 	Author string `json:"author"`
 	PostID string `json:"post_id"`
 }
 
+// Type implements Msg.
+func (msg DeletePostMsg) Type() string { return PostRoute }
+
+// GetSigner returns the username whose key signs the message.
+func (msg DeletePostMsg) GetSigner() string { return msg.Author }
+
 type DonateMsg struct {
 	Username string `json:"username"`
 	Amount   string `json:"amount"`
@@ -98,12 +227,24 @@ type DonateMsg struct {
 	Memo     string `json:"memo"`
 }
 
+// Type implements Msg.
+func (msg DonateMsg) Type() string { return PostRoute }
+
+// GetSigner returns the username whose key signs the message.
+func (msg DonateMsg) GetSigner() string { return msg.Username }
+
 type ViewMsg struct {
 	Username string `json:"username"`
 	Author   string `json:"author"`
 	PostID   string `json:"post_id"`
 }
 
+// Type implements Msg.
+func (msg ViewMsg) Type() string { return PostRoute }
+
+// GetSigner returns the username whose key signs the message.
+func (msg ViewMsg) GetSigner() string { return msg.Username }
+
 type ReportOrUpvoteMsg struct {
 	Username string `json:"username"`
 	Author   string `json:"author"`
@@ -111,6 +252,12 @@ type ReportOrUpvoteMsg struct {
 	IsReport bool   `json:"is_report"`
 }
 
+// Type implements Msg.
+func (msg ReportOrUpvoteMsg) Type() string { return PostRoute }
+
+// GetSigner returns the username whose key signs the message.
+func (msg ReportOrUpvoteMsg) GetSigner() string { return msg.Username }
+
 //
 // Validator related messages
 //
@@ -121,15 +268,33 @@ type ValidatorDepositMsg struct {
 	Link      string        `json:"link"`
 }
 
+// Type implements Msg.
+func (msg ValidatorDepositMsg) Type() string { return ValidatorRoute }
+
+// GetSigner returns the username whose key signs the message.
+func (msg ValidatorDepositMsg) GetSigner() string { return msg.Username }
+
 type ValidatorWithdrawMsg struct {
 	Username string `json:"username"`
 	Amount   string `json:"amount"`
 }
 
+// Type implements Msg.
+func (msg ValidatorWithdrawMsg) Type() string { return ValidatorRoute }
+
+// GetSigner returns the username whose key signs the message.
+func (msg ValidatorWithdrawMsg) GetSigner() string { return msg.Username }
+
 type ValidatorRevokeMsg struct {
 	Username string `json:"username"`
 }
 
+// Type implements Msg.
+func (msg ValidatorRevokeMsg) Type() string { return ValidatorRoute }
+
+// GetSigner returns the username whose key signs the message.
+func (msg ValidatorRevokeMsg) GetSigner() string { return msg.Username }
+
 //
 // Vote related messages
 //
@@ -138,27 +303,57 @@ type StakeInMsg struct {
 	Deposit  string `json:"deposit"`
 }
 
+// Type implements Msg.
+func (msg StakeInMsg) Type() string { return VoteRoute }
+
+// GetSigner returns the username whose key signs the message.
+func (msg StakeInMsg) GetSigner() string { return msg.Username }
+
 type StakeOutMsg struct {
 	Username string `json:"username"`
 	Amount   string `json:"amount"`
 }
 
+// Type implements Msg.
+func (msg StakeOutMsg) Type() string { return VoteRoute }
+
+// GetSigner returns the username whose key signs the message.
+func (msg StakeOutMsg) GetSigner() string { return msg.Username }
+
 type DelegateMsg struct {
 	Delegator string `json:"delegator"`
 	Voter     string `json:"voter"`
 	Amount    string `json:"amount"`
 }
 
+// Type implements Msg.
+func (msg DelegateMsg) Type() string { return VoteRoute }
+
+// GetSigner returns the username whose key signs the message.
+func (msg DelegateMsg) GetSigner() string { return msg.Delegator }
+
 type DelegatorWithdrawMsg struct {
 	Delegator string `json:"delegator"`
 	Voter     string `json:"voter"`
 	Amount    string `json:"amount"`
 }
 
+// Type implements Msg.
+func (msg DelegatorWithdrawMsg) Type() string { return VoteRoute }
+
+// GetSigner returns the username whose key signs the message.
+func (msg DelegatorWithdrawMsg) GetSigner() string { return msg.Delegator }
+
 type ClaimInterestMsg struct {
 	Username string `json:"username"`
 }
 
+// Type implements Msg.
+func (msg ClaimInterestMsg) Type() string { return VoteRoute }
+
+// GetSigner returns the username whose key signs the message.
+func (msg ClaimInterestMsg) GetSigner() string { return msg.Username }
+
 //
 // developer related messages
 //
@@ -170,6 +365,12 @@ type DeveloperRegisterMsg struct {
 	AppMetaData string `json:"app_meta_data"`
 }
 
+// Type implements Msg.
+func (msg DeveloperRegisterMsg) Type() string { return DeveloperRoute }
+
+// GetSigner returns the username whose key signs the message.
+func (msg DeveloperRegisterMsg) GetSigner() string { return msg.Username }
+
 type DeveloperUpdateMsg struct {
 	Username    string `json:"username"`
 	Website     string `json:"website"`
@@ -177,10 +378,22 @@ type DeveloperUpdateMsg struct {
 	AppMetaData string `json:"app_meta_data"`
 }
 
+// Type implements Msg.
+func (msg DeveloperUpdateMsg) Type() string { return DeveloperRoute }
+
+// GetSigner returns the username whose key signs the message.
+func (msg DeveloperUpdateMsg) GetSigner() string { return msg.Username }
+
 type DeveloperRevokeMsg struct {
 	Username string `json:"username"`
 }
 
+// Type implements Msg.
+func (msg DeveloperRevokeMsg) Type() string { return DeveloperRoute }
+
+// GetSigner returns the username whose key signs the message.
+func (msg DeveloperRevokeMsg) GetSigner() string { return msg.Username }
+
 type GrantPermissionMsg struct {
 	Username          string     `json:"username"`
 	AuthorizedApp     string     `json:"authorized_app"`
@@ -188,11 +401,23 @@ type GrantPermissionMsg struct {
 	GrantLevel        Permission `json:"grant_level"`
 }
 
+// Type implements Msg.
+func (msg GrantPermissionMsg) Type() string { return DeveloperRoute }
+
+// GetSigner returns the username whose key signs the message.
+func (msg GrantPermissionMsg) GetSigner() string { return msg.Username }
+
 type RevokePermissionMsg struct {
 	Username string        `json:"username"`
 	PubKey   crypto.PubKey `json:"public_key"`
 }
 
+// Type implements Msg.
+func (msg RevokePermissionMsg) Type() string { return DeveloperRoute }
+
+// GetSigner returns the username whose key signs the message.
+func (msg RevokePermissionMsg) GetSigner() string { return msg.Username }
+
 type PreAuthorizationMsg struct {
 	Username          string `json:"username"`
 	AuthorizedApp     string `json:"authorized_app"`
@@ -200,6 +425,12 @@ type PreAuthorizationMsg struct {
 	Amount            string `json:"amount"`
 }
 
+// Type implements Msg.
+func (msg PreAuthorizationMsg) Type() string { return DeveloperRoute }
+
+// GetSigner returns the username whose key signs the message.
+func (msg PreAuthorizationMsg) GetSigner() string { return msg.Username }
+
 //
 // infra related messages
 //
@@ -208,6 +439,12 @@ type ProviderReportMsg struct {
 	Usage    int64  `json:"usage"`
 }
 
+// Type implements Msg.
+func (msg ProviderReportMsg) Type() string { return InfraRoute }
+
+// GetSigner returns the username whose key signs the message.
+func (msg ProviderReportMsg) GetSigner() string { return msg.Username }
+
 //
 // proposal related messages
 //
@@ -217,74 +454,152 @@ type DeletePostContentMsg struct {
 	Reason   string `json:"reason"`
 }
 
+// Type implements Msg.
+func (msg DeletePostContentMsg) Type() string { return ProposalRoute }
+
+// GetSigner returns the username whose key signs the message.
+func (msg DeletePostContentMsg) GetSigner() string { return msg.Creator }
+
 type UpgradeProtocolMsg struct {
 	Creator string `json:"creator"`
 	Link    string `json:"link"`
 	Reason  string `json:"reason"`
 }
 
+// Type implements Msg.
+func (msg UpgradeProtocolMsg) Type() string { return ProposalRoute }
+
+// GetSigner returns the username whose key signs the message.
+func (msg UpgradeProtocolMsg) GetSigner() string { return msg.Creator }
+
 type ChangeGlobalAllocationParamMsg struct {
 	Creator   string                `json:"creator"`
 	Parameter GlobalAllocationParam `json:"parameter"`
 	Reason    string                `json:"reason"`
 }
 
+// Type implements Msg.
+func (msg ChangeGlobalAllocationParamMsg) Type() string { return ProposalRoute }
+
+// GetSigner returns the username whose key signs the message.
+func (msg ChangeGlobalAllocationParamMsg) GetSigner() string { return msg.Creator }
+
 type ChangeEvaluateOfContentValueParamMsg struct {
 	Creator   string                      `json:"creator"`
 	Parameter EvaluateOfContentValueParam `json:"parameter"`
 	Reason    string                      `json:"reason"`
 }
 
+// Type implements Msg.
+func (msg ChangeEvaluateOfContentValueParamMsg) Type() string { return ProposalRoute }
+
+// GetSigner returns the username whose key signs the message.
+func (msg ChangeEvaluateOfContentValueParamMsg) GetSigner() string { return msg.Creator }
+
 type ChangeInfraInternalAllocationParamMsg struct {
 	Creator   string                       `json:"creator"`
 	Parameter InfraInternalAllocationParam `json:"parameter"`
 	Reason    string                       `json:"reason"`
 }
 
+// Type implements Msg.
+func (msg ChangeInfraInternalAllocationParamMsg) Type() string { return ProposalRoute }
+
+// GetSigner returns the username whose key signs the message.
+func (msg ChangeInfraInternalAllocationParamMsg) GetSigner() string { return msg.Creator }
+
 type ChangeVoteParamMsg struct {
 	Creator   string    `json:"creator"`
 	Parameter VoteParam `json:"parameter"`
 	Reason    string    `json:"reason"`
 }
 
+// Type implements Msg.
+func (msg ChangeVoteParamMsg) Type() string { return ProposalRoute }
+
+// GetSigner returns the username whose key signs the message.
+func (msg ChangeVoteParamMsg) GetSigner() string { return msg.Creator }
+
 type ChangeProposalParamMsg struct {
 	Creator   string        `json:"creator"`
 	Parameter ProposalParam `json:"parameter"`
 	Reason    string        `json:"reason"`
 }
 
+// Type implements Msg.
+func (msg ChangeProposalParamMsg) Type() string { return ProposalRoute }
+
+// GetSigner returns the username whose key signs the message.
+func (msg ChangeProposalParamMsg) GetSigner() string { return msg.Creator }
+
 type ChangeDeveloperParamMsg struct {
 	Creator   string         `json:"creator"`
 	Parameter DeveloperParam `json:"parameter"`
 	Reason    string         `json:"reason"`
 }
 
+// Type implements Msg.
+func (msg ChangeDeveloperParamMsg) Type() string { return ProposalRoute }
+
+// GetSigner returns the username whose key signs the message.
+func (msg ChangeDeveloperParamMsg) GetSigner() string { return msg.Creator }
+
 type ChangeValidatorParamMsg struct {
 	Creator   string         `json:"creator"`
 	Parameter ValidatorParam `json:"parameter"`
 	Reason    string         `json:"reason"`
 }
 
+// Type implements Msg.
+func (msg ChangeValidatorParamMsg) Type() string { return ProposalRoute }
+
+// GetSigner returns the username whose key signs the message.
+func (msg ChangeValidatorParamMsg) GetSigner() string { return msg.Creator }
+
 type ChangeBandwidthParamMsg struct {
 	Creator   string         `json:"creator"`
 	Parameter BandwidthParam `json:"parameter"`
 	Reason    string         `json:"reason"`
 }
 
+// Type implements Msg.
+func (msg ChangeBandwidthParamMsg) Type() string { return ProposalRoute }
+
+// GetSigner returns the username whose key signs the message.
+func (msg ChangeBandwidthParamMsg) GetSigner() string { return msg.Creator }
+
 type ChangeAccountParamMsg struct {
 	Creator   string       `json:"creator"`
 	Parameter AccountParam `json:"parameter"`
 	Reason    string       `json:"reason"`
 }
 
+// Type implements Msg.
+func (msg ChangeAccountParamMsg) Type() string { return ProposalRoute }
+
+// GetSigner returns the username whose key signs the message.
+func (msg ChangeAccountParamMsg) GetSigner() string { return msg.Creator }
+
 type ChangePostParamMsg struct {
 	Creator   string    `json:"creator"`
 	Parameter PostParam `json:"parameter"`
 	Reason    string    `json:"reason"`
 }
 
+// Type implements Msg.
+func (msg ChangePostParamMsg) Type() string { return ProposalRoute }
+
+// GetSigner returns the username whose key signs the message.
+func (msg ChangePostParamMsg) GetSigner() string { return msg.Creator }
+
 type VoteProposalMsg struct {
 	Voter      string `json:"voter"`
 	ProposalID string `json:"proposal_id"`
 	Result     bool   `json:"result"`
 }
+
+// Type implements Msg.
+func (msg VoteProposalMsg) Type() string { return ProposalRoute }
+
+// GetSigner returns the username whose key signs the message.
+func (msg VoteProposalMsg) GetSigner() string { return msg.Voter }
@@ -1,6 +1,10 @@
 package model
 
 import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"strings"
 	"time"
 
 	crypto "github.com/tendermint/tendermint/crypto"
@@ -16,6 +20,36 @@ type AccountInfo struct {
 	ResetKey       crypto.PubKey `json:"reset_key"`
 	TransactionKey crypto.PubKey `json:"transaction_key"`
 	AppKey         crypto.PubKey `json:"app_key"`
+	// Address is the hex address of the transaction key, see FillAddress.
+	Address string `json:"address,omitempty"`
+}
+
+// FillAddress sets Address to the address of the transaction key if it is empty.
+// The blockchain doesn't store the address, so it is derived on the client.
+func (info *AccountInfo) FillAddress() {
+	if info.Address == "" && info.TransactionKey != nil {
+		info.Address = AddressFromPubKey(info.TransactionKey)
+	}
+}
+
+// AddressFromPubKey returns the address of pubKey in uppercase hex.
+func AddressFromPubKey(pubKey crypto.PubKey) string {
+	return strings.ToUpper(hex.EncodeToString(pubKey.Address()))
+}
+
+// HasKey returns true if pubHex is the hex of the reset, transaction or app key of the account,
+// in the same format as returned by GetTransactionPubKey.
+func (info AccountInfo) HasKey(pubHex string) bool {
+	pubBytes, err := hex.DecodeString(pubHex)
+	if err != nil || len(pubBytes) == 0 {
+		return false
+	}
+	for _, key := range []crypto.PubKey{info.ResetKey, info.TransactionKey, info.AppKey} {
+		if key != nil && bytes.Equal(key.Bytes(), pubBytes) {
+			return true
+		}
+	}
+	return false
 }
 
 type AccountBank struct {
@@ -26,6 +60,43 @@ type AccountBank struct {
 	NumOfReward     int64         `json:"number_of_reward"`
 }
 
+// AccountState is the bank and the meta of an account read at the same Height.
+type AccountState struct {
+	Height int64        `json:"height"`
+	Bank   *AccountBank `json:"bank"`
+	Meta   *AccountMeta `json:"meta"`
+}
+
+// Account merges the info and the bank of an account.
+type Account struct {
+	Username        string        `json:"username"`
+	CreatedAt       int64         `json:"created_at"`
+	ResetKey        crypto.PubKey `json:"reset_key"`
+	TransactionKey  crypto.PubKey `json:"transaction_key"`
+	AppKey          crypto.PubKey `json:"app_key"`
+	Saving          Coin          `json:"saving"`
+	CoinDay         Coin          `json:"coin_day"`
+	FrozenMoneyList []FrozenMoney `json:"frozen_money_list"`
+	NumOfTx         int64         `json:"number_of_transaction"`
+	NumOfReward     int64         `json:"number_of_reward"`
+}
+
+// Stake is the coin a user has locked in each role, Total is the sum of all roles.
+type Stake struct {
+	VoterDeposit     Coin `json:"voter_deposit"`
+	ValidatorDeposit Coin `json:"validator_deposit"`
+	DeveloperDeposit Coin `json:"developer_deposit"`
+	Total            Coin `json:"total"`
+}
+
+// StakeRoles holds the records of the roles a user has staked in,
+// a role the user doesn't have is nil.
+type StakeRoles struct {
+	Voter     *Voter     `json:"voter"`
+	Validator *Validator `json:"validator"`
+	Developer *Developer `json:"developer"`
+}
+
 type FrozenMoney struct {
 	Amount   Coin  `json:"amount"`
 	StartAt  int64 `json:"start_at"`
@@ -67,6 +138,33 @@ type AccountMeta struct {
 	LastPostAt           int64  `json:"last_post_at"`
 }
 
+// CurrentCapacity returns the transaction capacity of the account at unix time now,
+// in seconds. The capacity recovers linearly from TransactionCapacity at LastActivityAt
+// to stake, the coin day of the account, in SecondsToRecoverBandwidth of param.
+func (meta AccountMeta) CurrentCapacity(stake Coin, param BandwidthParam, now int64) Coin {
+	if !stake.IsGT(meta.TransactionCapacity) {
+		return stake
+	}
+	elapsed := now - meta.LastActivityAt
+	if elapsed <= 0 {
+		return meta.TransactionCapacity
+	}
+	if param.SecondsToRecoverBandwidth <= 0 || elapsed >= param.SecondsToRecoverBandwidth {
+		return stake
+	}
+
+	capacityTillStake := stake.Minus(meta.TransactionCapacity)
+	increase := capacityTillStake.Amount.Mul(Int{big.NewInt(elapsed)}).Div(Int{big.NewInt(param.SecondsToRecoverBandwidth)})
+	return meta.TransactionCapacity.Plus(Coin{Amount: increase})
+}
+
+// CanAffordTx returns true if the capacity of the account at unix time now
+// covers the capacity used by one transaction. The blockchain charges less
+// than CapacityUsagePerTransaction when it is not busy, so this is the worst case.
+func (meta AccountMeta) CanAffordTx(stake Coin, param BandwidthParam, now int64) bool {
+	return meta.CurrentCapacity(stake, param, now).IsGTE(param.CapacityUsagePerTransaction)
+}
+
 type FollowerMeta struct {
 	CreatedAt    int64  `json:"created_at"`
 	FollowerName string `json:"follower_name"`
@@ -85,13 +183,21 @@ type Reward struct {
 	UnclaimReward   Coin `json:"unclaim_reward"`
 }
 
+// Claimable returns the reward paid out by a Claim now. Claim releases the whole
+// UnclaimReward at once, there is no vesting schedule on it. Rewards of recent
+// donations are added to UnclaimReward only when the blockchain executes their
+// reward event, until then they are not part of Reward.
+func (reward Reward) Claimable() Coin {
+	return reward.UnclaimReward
+}
+
 type RewardDetail struct {
 	OriginalDonation Coin   `json:"original_donation"`
 	FrictionDonation Coin   `json:"friction_donation"`
 	ActualReward     Coin   `json:"actual_reward"`
 	Consumer         string `json:"consumer"`
 	PostAuthor       string `json:"post_author"`
-	PostID           string `json:"post_id`
+	PostID           string `json:"post_id"`
 }
 
 type RewardHistory struct {
@@ -131,6 +237,17 @@ type PostInfo struct {
 	Links        []IDToURLMapping `json:"links"`
 }
 
+// Permlink returns the permlink of the post, see model.Permlink.
+func (info PostInfo) Permlink() string {
+	return Permlink(info.Author, info.PostID)
+}
+
+// IsCleared returns true if the title, the content and the links of the post
+// are empty, as they are after the post is deleted.
+func (info PostInfo) IsCleared() bool {
+	return info.Title == "" && info.Content == "" && len(info.Links) == 0
+}
+
 // PostMeta stores tiny and frequently updated fields.
 type PostMeta struct {
 	CreatedAt               int64  `json:"created_at"`
@@ -146,6 +263,13 @@ type PostMeta struct {
 	RedistributionSplitRate string `json:"redistribution_split_rate"`
 }
 
+// NetUpvoteCoinDay returns the upvote coin day minus the report coin day of the post,
+// negative if reports outweigh upvotes. The coin day of a user is added when the
+// user upvotes or reports, so the totals don't change with the block time.
+func (meta PostMeta) NetUpvoteCoinDay() Coin {
+	return meta.TotalUpvoteCoinDay.Minus(meta.TotalReportCoinDay)
+}
+
 // Post is the combination of PostInfo and PostMeta.
 type Post struct {
 	PostID                  string           `json:"post_id"`
@@ -170,6 +294,20 @@ type Post struct {
 	RedistributionSplitRate string           `json:"redistribution_split_rate"`
 }
 
+// Permlink returns the permlink of the post, see model.Permlink.
+func (post Post) Permlink() string {
+	return Permlink(post.Author, post.PostID)
+}
+
+// PostEngagement holds the views, comments, donations and reports or upvotes
+// of a post, keyed by username, or by comment permlink for comments.
+type PostEngagement struct {
+	Views           map[string]*View           `json:"views"`
+	Comments        map[string]*Comment        `json:"comments"`
+	Donations       map[string]*Donations      `json:"donations"`
+	ReportOrUpvotes map[string]*ReportOrUpvote `json:"report_or_upvotes"`
+}
+
 type ReportOrUpvote struct {
 	Username  string `json:"username"`
 	CoinDay   Coin   `json:"coin_day"`
@@ -183,6 +321,16 @@ type Comment struct {
 	CreatedAt int64  `json:"created_at"`
 }
 
+type CommentNode struct {
+	Comment *Comment       `json:"comment"`
+	Replies []*CommentNode `json:"replies"`
+}
+
+type CommentTree struct {
+	Comments  []*CommentNode `json:"comments"`
+	Truncated bool           `json:"truncated"`
+}
+
 type View struct {
 	Username   string `json:"username"`
 	LastViewAt int64  `json:"last_view_at"`
@@ -195,6 +343,13 @@ type Donations struct {
 	Amount   Coin   `json:"amount"`
 }
 
+type UserDonations struct {
+	Donor       string                `json:"donor"`
+	TotalTimes  int64                 `json:"total_times"`
+	TotalAmount Coin                  `json:"total_amount"`
+	Posts       map[string]*Donations `json:"posts"`
+}
+
 //
 // validator related struct
 //
@@ -219,14 +374,59 @@ type Validator struct {
 	Link            string `json:"link"`
 }
 
+// ValidatorUptime is the share of the last Window blocks signed by a validator,
+// Percentage ranges from 0 to 100.
+type ValidatorUptime struct {
+	Username   string  `json:"username"`
+	Window     int64   `json:"window"`
+	Missed     int64   `json:"missed"`
+	Percentage float64 `json:"percentage"`
+}
+
+// ValidatorSlashRisk estimates the penalty of a validator for missing commits.
+// The validator is punished by Slash once AbsentCommit exceeds Threshold,
+// after which its absent commits start over from 0.
+type ValidatorSlashRisk struct {
+	Username     string `json:"username"`
+	AbsentCommit int64  `json:"absent_commit"`
+	Threshold    int64  `json:"threshold"`
+	// Remaining is how many more commits the validator can miss without penalty.
+	Remaining int64 `json:"remaining"`
+	// Slash is PenaltyMissCommit of ValidatorParam, capped by the deposit of the validator.
+	Slash Coin `json:"slash"`
+}
+
+// RankedValidator is a validator with its voting power, which is its deposit
+// plus the power delegated to it as a voter.
+type RankedValidator struct {
+	Username       string `json:"username"`
+	Deposit        Coin   `json:"deposit"`
+	DelegatedPower Coin   `json:"delegated_power"`
+	VotingPower    Coin   `json:"voting_power"`
+}
+
+// ValidatorList holds the three validator sets of Lino blockchain.
 type ValidatorList struct {
-	OncallValidators   []string `json:"oncall_validators"`
-	AllValidators      []string `json:"all_validators"`
+	// OncallValidators are the validators producing blocks,
+	// the ones with the highest power among AllValidators.
+	OncallValidators []string `json:"oncall_validators"`
+	// AllValidators are all registered validators, oncall or standby.
+	AllValidators []string `json:"all_validators"`
+	// PreBlockValidators are the oncall validators of the previous block.
+	// The blockchain compares them with OncallValidators to send validator
+	// set updates to Tendermint and to count absent commits.
 	PreBlockValidators []string `json:"pre_block_validators"`
 	LowestPower        Coin     `json:"lowest_power"`
 	LowestValidator    string   `json:"lowest_validator"`
 }
 
+type ValidatorSetChanges struct {
+	FromHeight int64    `json:"from_height"`
+	ToHeight   int64    `json:"to_height"`
+	Added      []string `json:"added"`
+	Removed    []string `json:"removed"`
+}
+
 //
 // vote related struct
 //
@@ -239,6 +439,11 @@ type Voter struct {
 	Interest          Coin   `json:"interest"`
 }
 
+// TotalPower returns the stake of the voter plus the power delegated to it.
+func (voter Voter) TotalPower() Coin {
+	return voter.LinoStake.Plus(voter.DelegatedPower)
+}
+
 type Vote struct {
 	Voter       string `json:"voter"`
 	VotingPower Coin   `json:"voting_power"`
@@ -270,6 +475,50 @@ type DeveloperList struct {
 	AllDevelopers []string `json:"all_developers"`
 }
 
+// DeveloperInflationEstimate is the share of the developer inflation pool a developer
+// would get if the pool were distributed now, in proportion to app consumption.
+type DeveloperInflationEstimate struct {
+	Username               string `json:"username"`
+	AppConsumption         Coin   `json:"app_consumption"`
+	TotalConsumption       Coin   `json:"total_consumption"`
+	DeveloperInflationPool Coin   `json:"developer_inflation_pool"`
+	EstimatedInflation     Coin   `json:"estimated_inflation"`
+}
+
+//
+// global related
+//
+
+// GlobalMeta is the supply of LNO.
+type GlobalMeta struct {
+	TotalLinoCoin Coin `json:"total_lino_coin"`
+	// LastYearTotalLinoCoin is the supply at the start of the current year, the
+	// base of the inflation of the year.
+	LastYearTotalLinoCoin Coin `json:"last_year_total_lino_coin"`
+}
+
+// InflationRate is the annual inflation and the resulting validator APR,
+// see Query.GetInflationRate for the assumptions.
+type InflationRate struct {
+	// GrowthRate is GlobalGrowthRate of GlobalAllocationParam, e.g. 0.098 for 9.8%.
+	GrowthRate      float64 `json:"growth_rate"`
+	AnnualInflation Coin    `json:"annual_inflation"`
+	// ValidatorAnnualInflation is the share of the validators, split equally among the oncall validators.
+	ValidatorAnnualInflation Coin `json:"validator_annual_inflation"`
+	OncallValidators         int  `json:"oncall_validators"`
+	// ValidatorAPR is the annual inflation of one oncall validator over the
+	// average deposit of the oncall validators, e.g. 0.2 for 20%.
+	ValidatorAPR float64 `json:"validator_apr"`
+}
+
+// InflationPool is the inflation not yet distributed to each role.
+type InflationPool struct {
+	InfraInflationPool          Coin `json:"infra_inflation_pool"`
+	ContentCreatorInflationPool Coin `json:"content_creator_inflation_pool"`
+	DeveloperInflationPool      Coin `json:"developer_inflation_pool"`
+	ValidatorInflationPool      Coin `json:"validator_inflation_pool"`
+}
+
 //
 // infra provider related
 //
@@ -285,7 +534,11 @@ type InfraProviderList struct {
 //
 // proposal related
 //
-type Proposal interface{}
+
+// Proposal is implemented by all kinds of proposals.
+type Proposal interface {
+	GetProposalInfo() ProposalInfo
+}
 
 type ProposalInfo struct {
 	Creator       string `json:"creator"`
@@ -298,6 +551,33 @@ type ProposalInfo struct {
 	Reason        string `json:"reason"`
 }
 
+// GetProposalInfo implements Proposal.
+func (info ProposalInfo) GetProposalInfo() ProposalInfo {
+	return info
+}
+
+// ProposalDeadline is when the voting of a proposal ends.
+type ProposalDeadline struct {
+	ProposalID string `json:"proposal_id"`
+	// ExpiredAt is the unix time in seconds when the voting ends.
+	ExpiredAt int64 `json:"expired_at"`
+	// RemainingSec is the time left at the latest block, 0 if the voting has ended.
+	RemainingSec int64 `json:"remaining_second"`
+}
+
+// ProposalOutcome is the state of the voting of a proposal. Result and Applied
+// are only meaningful once Decided, the votes are the final tallies then.
+type ProposalOutcome struct {
+	ProposalID    string         `json:"proposal_id"`
+	Decided       bool           `json:"decided"`
+	Result        ProposalResult `json:"result"`
+	AgreeVotes    Coin           `json:"agree_vote"`
+	DisagreeVotes Coin           `json:"disagree_vote"`
+	// Applied is true if the blockchain carried out the passed proposal,
+	// a protocol upgrade has to be carried out by the validators instead.
+	Applied bool `json:"applied"`
+}
+
 type ChangeParamProposal struct {
 	ProposalInfo
 	Param  Parameter `json:"param"`
@@ -310,12 +590,56 @@ type ContentCensorshipProposal struct {
 	Reason   string `json:"reason"`
 }
 
+// ContentDeletion is a passed content censorship proposal, after which
+// the content of the post was deleted by the blockchain.
+type ContentDeletion struct {
+	ProposalID string `json:"proposal_id"`
+	Creator    string `json:"creator"`
+	Permlink   string `json:"permlink"`
+	// Reason is the reason given to DeletePostContent.
+	Reason string `json:"reason"`
+	// DeletedAt is the unix time in seconds when the voting ended.
+	DeletedAt int64 `json:"deleted_at"`
+}
+
 type ProtocolUpgradeProposal struct {
 	ProposalInfo
 	Link   string `json:"link"`
 	Reason string `json:"reason"`
 }
 
+// GetProposalType returns the kind of proposal, false if it is of an unknown kind.
+func GetProposalType(proposal Proposal) (ProposalType, bool) {
+	switch proposal.(type) {
+	case *ChangeParamProposal, ChangeParamProposal:
+		return ChangeParamProposalType, true
+	case *ContentCensorshipProposal, ContentCensorshipProposal:
+		return ContentCensorshipProposalType, true
+	case *ProtocolUpgradeProposal, ProtocolUpgradeProposal:
+		return ProtocolUpgradeProposalType, true
+	default:
+		return 0, false
+	}
+}
+
+// ProposalListOptions selects a page of a proposal list. The zero value selects all proposals.
+type ProposalListOptions struct {
+	// Types, if set, keeps only the proposals of these kinds.
+	Types []ProposalType
+	// Cursor, if set, starts the page after the proposal it was returned with
+	// as ProposalPage.NextCursor.
+	Cursor string
+	// Limit, if positive, is the max number of proposals in the page.
+	Limit int
+}
+
+// ProposalPage is a page of a proposal list in ascending order of proposal ID.
+type ProposalPage struct {
+	Proposals []Proposal `json:"proposals"`
+	// NextCursor is the cursor of the next page, empty on the last page.
+	NextCursor string `json:"next_cursor"`
+}
+
 type NextProposalID struct {
 	NextProposalID int64 `json:"next_proposal_id"`
 }
@@ -347,6 +671,42 @@ type BlockTx struct {
 	Tx     Transaction `json:"tx"`
 }
 
+// BlockResults is the outcome of executing the block at Height.
+// TxResults are in the same order as the transactions in the block.
+type BlockResults struct {
+	Height           int64       `json:"height"`
+	TxResults        []*TxResult `json:"tx_results"`
+	BeginBlockEvents []Tag       `json:"begin_block_events"`
+	EndBlockEvents   []Tag       `json:"end_block_events"`
+}
+
+// TxResult is the DeliverTx result of a transaction.
+type TxResult struct {
+	Code      uint32 `json:"code"`
+	Log       string `json:"log"`
+	Info      string `json:"info"`
+	GasWanted int64  `json:"gas_wanted"`
+	GasUsed   int64  `json:"gas_used"`
+	Events    []Tag  `json:"events"`
+}
+
+// Tag is a decoded event attribute emitted by the blockchain.
+type Tag struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// BroadcastResponse is the result of a broadcast. Height and BlockTime are
+// only set once the transaction is committed, i.e. not for a sync broadcast.
 type BroadcastResponse struct {
-	CommitHash string `json:"commit_hash"`
+	CommitHash string    `json:"commit_hash"`
+	Height     int64     `json:"height"`
+	BlockTime  time.Time `json:"block_time"`
+}
+
+// CommittedTxResponse is the result of a broadcast which waits until the
+// transaction is committed, with the DeliverTx result and its decoded events.
+type CommittedTxResponse struct {
+	BroadcastResponse
+	Result *TxResult `json:"result"`
 }
@@ -0,0 +1,252 @@
+package model
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/tendermint/tendermint/crypto/secp256k1"
+)
+
+func TestAccountInfoHasKey(t *testing.T) {
+	resetKey := secp256k1.GenPrivKey().PubKey()
+	txKey := secp256k1.GenPrivKey().PubKey()
+	appKey := secp256k1.GenPrivKey().PubKey()
+	otherKey := secp256k1.GenPrivKey().PubKey()
+	info := AccountInfo{
+		Username:       "user1",
+		ResetKey:       resetKey,
+		TransactionKey: txKey,
+		AppKey:         appKey,
+	}
+
+	testCases := map[string]struct {
+		pubHex    string
+		expectHas bool
+	}{
+		"reset key": {
+			pubHex:    hex.EncodeToString(resetKey.Bytes()),
+			expectHas: true,
+		},
+		"upper case transaction key": {
+			pubHex:    strings.ToUpper(hex.EncodeToString(txKey.Bytes())),
+			expectHas: true,
+		},
+		"app key": {
+			pubHex:    hex.EncodeToString(appKey.Bytes()),
+			expectHas: true,
+		},
+		"other key": {
+			pubHex:    hex.EncodeToString(otherKey.Bytes()),
+			expectHas: false,
+		},
+		"invalid hex": {
+			pubHex:    "xyz",
+			expectHas: false,
+		},
+		"empty hex": {
+			pubHex:    "",
+			expectHas: false,
+		},
+	}
+
+	for testName, tc := range testCases {
+		if got := info.HasKey(tc.pubHex); got != tc.expectHas {
+			t.Errorf("%s: diff has key, got %v, want %v", testName, got, tc.expectHas)
+		}
+	}
+}
+
+func TestAccountInfoFillAddress(t *testing.T) {
+	txKey := secp256k1.GenPrivKey().PubKey()
+	expectAddr := strings.ToUpper(hex.EncodeToString(txKey.Address()))
+
+	testCases := map[string]struct {
+		info       AccountInfo
+		expectAddr string
+	}{
+		"derive when empty": {
+			info:       AccountInfo{TransactionKey: txKey},
+			expectAddr: expectAddr,
+		},
+		"keep existing address": {
+			info:       AccountInfo{TransactionKey: txKey, Address: "ABCD"},
+			expectAddr: "ABCD",
+		},
+		"no transaction key": {
+			info:       AccountInfo{},
+			expectAddr: "",
+		},
+	}
+
+	for testName, tc := range testCases {
+		tc.info.FillAddress()
+		if tc.info.Address != tc.expectAddr {
+			t.Errorf("%s: diff address, got %v, want %v", testName, tc.info.Address, tc.expectAddr)
+		}
+	}
+}
+
+func TestAccountMetaCurrentCapacity(t *testing.T) {
+	param := BandwidthParam{
+		SecondsToRecoverBandwidth:   100,
+		CapacityUsagePerTransaction: NewCoinFromInt64(10),
+	}
+	meta := AccountMeta{
+		LastActivityAt:      1000,
+		TransactionCapacity: NewCoinFromInt64(5),
+	}
+
+	testCases := map[string]struct {
+		stake          Coin
+		now            int64
+		expectCapacity Coin
+		expectAfford   bool
+	}{
+		"no time passed": {
+			stake:          NewCoinFromInt64(105),
+			now:            1000,
+			expectCapacity: NewCoinFromInt64(5),
+			expectAfford:   false,
+		},
+		"partially recovered": {
+			stake:          NewCoinFromInt64(105),
+			now:            1010,
+			expectCapacity: NewCoinFromInt64(15),
+			expectAfford:   true,
+		},
+		"fully recovered": {
+			stake:          NewCoinFromInt64(105),
+			now:            1200,
+			expectCapacity: NewCoinFromInt64(105),
+			expectAfford:   true,
+		},
+		"stake below capacity": {
+			stake:          NewCoinFromInt64(3),
+			now:            1200,
+			expectCapacity: NewCoinFromInt64(3),
+			expectAfford:   false,
+		},
+	}
+
+	for testName, tc := range testCases {
+		capacity := meta.CurrentCapacity(tc.stake, param, tc.now)
+		if !capacity.IsEqual(tc.expectCapacity) {
+			t.Errorf("%s: diff capacity, got %v, want %v", testName, capacity, tc.expectCapacity)
+		}
+		if afford := meta.CanAffordTx(tc.stake, param, tc.now); afford != tc.expectAfford {
+			t.Errorf("%s: diff can afford, got %v, want %v", testName, afford, tc.expectAfford)
+		}
+	}
+}
+
+func TestPostInfoIsCleared(t *testing.T) {
+	testCases := map[string]struct {
+		info          PostInfo
+		expectCleared bool
+	}{
+		"deleted post": {
+			info:          PostInfo{Author: "user1", PostID: "post1"},
+			expectCleared: true,
+		},
+		"post with title": {
+			info:          PostInfo{Author: "user1", PostID: "post1", Title: "title"},
+			expectCleared: false,
+		},
+		"post with content": {
+			info:          PostInfo{Author: "user1", PostID: "post1", Content: "content"},
+			expectCleared: false,
+		},
+		"post with links": {
+			info: PostInfo{
+				Author: "user1",
+				PostID: "post1",
+				Links:  []IDToURLMapping{{Identifier: "site", URL: "https://lino.network"}},
+			},
+			expectCleared: false,
+		},
+	}
+
+	for testName, tc := range testCases {
+		if cleared := tc.info.IsCleared(); cleared != tc.expectCleared {
+			t.Errorf("%s: diff cleared, got %v, want %v", testName, cleared, tc.expectCleared)
+		}
+	}
+}
+
+func TestPostPermlink(t *testing.T) {
+	testCases := map[string]struct {
+		author         string
+		postID         string
+		expectPermlink string
+	}{
+		"post": {
+			author:         "user1",
+			postID:         "post1",
+			expectPermlink: "user1#post1",
+		},
+		"post ID with separator": {
+			author:         "user1",
+			postID:         "post#1",
+			expectPermlink: "user1#post#1",
+		},
+	}
+
+	for testName, tc := range testCases {
+		if permlink := Permlink(tc.author, tc.postID); permlink != tc.expectPermlink {
+			t.Errorf("%s: diff permlink, got %v, want %v", testName, permlink, tc.expectPermlink)
+		}
+		info := PostInfo{Author: tc.author, PostID: tc.postID}
+		if permlink := info.Permlink(); permlink != Permlink(tc.author, tc.postID) {
+			t.Errorf("%s: diff permlink of post info, got %v, want %v", testName, permlink, Permlink(tc.author, tc.postID))
+		}
+		post := Post{Author: tc.author, PostID: tc.postID}
+		if permlink := post.Permlink(); permlink != Permlink(tc.author, tc.postID) {
+			t.Errorf("%s: diff permlink of post, got %v, want %v", testName, permlink, Permlink(tc.author, tc.postID))
+		}
+	}
+}
+
+func TestVoterTotalPower(t *testing.T) {
+	testCases := map[string]struct {
+		voter  Voter
+		expect Coin
+	}{
+		"no delegation": {
+			voter:  Voter{LinoStake: NewCoinFromInt64(100), DelegatedPower: NewCoinFromInt64(0)},
+			expect: NewCoinFromInt64(100),
+		},
+		"with delegation": {
+			voter:  Voter{LinoStake: NewCoinFromInt64(100), DelegatedPower: NewCoinFromInt64(50)},
+			expect: NewCoinFromInt64(150),
+		},
+	}
+
+	for testName, tc := range testCases {
+		if power := tc.voter.TotalPower(); !power.IsEqual(tc.expect) {
+			t.Errorf("%s: diff total power, got %v, want %v", testName, power, tc.expect)
+		}
+	}
+}
+
+func TestPostMetaNetUpvoteCoinDay(t *testing.T) {
+	testCases := map[string]struct {
+		meta   PostMeta
+		expect Coin
+	}{
+		"more upvotes": {
+			meta:   PostMeta{TotalUpvoteCoinDay: NewCoinFromInt64(100), TotalReportCoinDay: NewCoinFromInt64(30)},
+			expect: NewCoinFromInt64(70),
+		},
+		"more reports": {
+			meta:   PostMeta{TotalUpvoteCoinDay: NewCoinFromInt64(30), TotalReportCoinDay: NewCoinFromInt64(100)},
+			expect: NewCoinFromInt64(-70),
+		},
+	}
+
+	for testName, tc := range testCases {
+		if net := tc.meta.NetUpvoteCoinDay(); !net.IsEqual(tc.expect) {
+			t.Errorf("%s: diff net upvote coin day, got %v, want %v", testName, net, tc.expect)
+		}
+	}
+}
@@ -1,8 +1,30 @@
 package model
 
+import "github.com/lino-network/lino-go/errors"
+
 type Permission int
 type DetailType int
 
+// ProposalType is the kind of a proposal, which decides its deposit and voting period.
+type ProposalType int
+
+// Different kinds of proposals
+const (
+	ChangeParamProposalType       = ProposalType(0)
+	ContentCensorshipProposalType = ProposalType(1)
+	ProtocolUpgradeProposalType   = ProposalType(2)
+)
+
+// ProposalResult is the outcome of a decided proposal, same as ProposalInfo.Result on Lino blockchain.
+type ProposalResult int
+
+// Different outcomes of a decided proposal
+const (
+	ProposalNotPass = ProposalResult(0)
+	ProposalPass    = ProposalResult(1)
+	ProposalRevoked = ProposalResult(2)
+)
+
 const (
 	InvalidSeqErrCode = 154
 
@@ -40,3 +62,94 @@ const (
 	InfraDeposit     = DetailType(26)
 	ProposalDeposit  = DetailType(27)
 )
+
+// Limits of message fields, same as on Lino blockchain.
+const (
+	MinimumUsernameLength = 3
+	MaximumUsernameLength = 20
+
+	MaximumMemoLength     = 100
+	MaximumJSONMetaLength = 500
+
+	MaximumLengthOfPostID = 50
+	MaxPostTitleLength    = 100
+	MaxPostContentLength  = 1000
+	MaximumNumOfLinks     = 10
+	MaximumLinkIdentifier = 20
+	MaximumLinkURL        = 50
+
+	MaximumLengthOfDeveloperWebsite     = 100
+	MaximumLengthOfDeveloperDescription = 1000
+	MaximumLengthOfAppMetadata          = 1000
+
+	MaximumLengthOfProposalReason = 1000
+)
+
+var permissionNames = map[Permission]string{
+	UnknownPermission:          "UnknownPermission",
+	AppPermission:              "AppPermission",
+	TransactionPermission:      "TransactionPermission",
+	ResetPermission:            "ResetPermission",
+	GrantAppPermission:         "GrantAppPermission",
+	PreAuthorizationPermission: "PreAuthorizationPermission",
+}
+
+// String returns the name of the permission, "Unknown" if it is not defined.
+func (p Permission) String() string {
+	if name, ok := permissionNames[p]; ok {
+		return name
+	}
+	return "Unknown"
+}
+
+// ParsePermission returns the permission named s, as returned by Permission.String.
+func ParsePermission(s string) (Permission, error) {
+	for p, name := range permissionNames {
+		if name == s {
+			return p, nil
+		}
+	}
+	return UnknownPermission, errors.InvalidArgf("unknown permission %q", s)
+}
+
+var detailTypeNames = map[DetailType]string{
+	TransferIn:           "TransferIn",
+	DonationIn:           "DonationIn",
+	ClaimReward:          "ClaimReward",
+	ValidatorInflation:   "ValidatorInflation",
+	DeveloperInflation:   "DeveloperInflation",
+	InfraInflation:       "InfraInflation",
+	VoteReturnCoin:       "VoteReturnCoin",
+	DelegationReturnCoin: "DelegationReturnCoin",
+	ValidatorReturnCoin:  "ValidatorReturnCoin",
+	DeveloperReturnCoin:  "DeveloperReturnCoin",
+	InfraReturnCoin:      "InfraReturnCoin",
+	ProposalReturnCoin:   "ProposalReturnCoin",
+	GenesisCoin:          "GenesisCoin",
+	ClaimInterest:        "ClaimInterest",
+
+	TransferOut:      "TransferOut",
+	DonationOut:      "DonationOut",
+	Delegate:         "Delegate",
+	VoterDeposit:     "VoterDeposit",
+	ValidatorDeposit: "ValidatorDeposit",
+	DeveloperDeposit: "DeveloperDeposit",
+	InfraDeposit:     "InfraDeposit",
+	ProposalDeposit:  "ProposalDeposit",
+}
+
+// String returns the name of the detail type, "Unknown" if it is not defined.
+func (t DetailType) String() string {
+	if name, ok := detailTypeNames[t]; ok {
+		return name
+	}
+	return "Unknown"
+}
+
+// PermLinkSeparator separates the author and the post ID in a permlink.
+const PermLinkSeparator = "#"
+
+// Permlink returns the permlink of a post, which is author#postID.
+func Permlink(author, postID string) string {
+	return author + PermLinkSeparator + postID
+}
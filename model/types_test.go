@@ -0,0 +1,60 @@
+package model
+
+import "testing"
+
+func TestDetailTypeString(t *testing.T) {
+	testCases := map[string]struct {
+		detailType   DetailType
+		expectString string
+	}{
+		"first income":   {detailType: TransferIn, expectString: "TransferIn"},
+		"last income":    {detailType: ClaimInterest, expectString: "ClaimInterest"},
+		"first outcome":  {detailType: TransferOut, expectString: "TransferOut"},
+		"last outcome":   {detailType: ProposalDeposit, expectString: "ProposalDeposit"},
+		"gap in range":   {detailType: DetailType(14), expectString: "Unknown"},
+		"out of range":   {detailType: DetailType(100), expectString: "Unknown"},
+		"negative value": {detailType: DetailType(-1), expectString: "Unknown"},
+	}
+
+	for testName, tc := range testCases {
+		if got := tc.detailType.String(); got != tc.expectString {
+			t.Errorf("%s: diff string, got %v, want %v", testName, got, tc.expectString)
+		}
+	}
+}
+
+func TestPermissionString(t *testing.T) {
+	testCases := map[string]struct {
+		permission   Permission
+		expectString string
+	}{
+		"unknown permission":     {permission: UnknownPermission, expectString: "UnknownPermission"},
+		"app permission":         {permission: AppPermission, expectString: "AppPermission"},
+		"transaction permission": {permission: TransactionPermission, expectString: "TransactionPermission"},
+		"reset permission":       {permission: ResetPermission, expectString: "ResetPermission"},
+		"grant app permission":   {permission: GrantAppPermission, expectString: "GrantAppPermission"},
+		"pre authorization":      {permission: PreAuthorizationPermission, expectString: "PreAuthorizationPermission"},
+		"undefined permission":   {permission: Permission(6), expectString: "Unknown"},
+		"negative permission":    {permission: Permission(-1), expectString: "Unknown"},
+	}
+
+	for testName, tc := range testCases {
+		if got := tc.permission.String(); got != tc.expectString {
+			t.Errorf("%s: diff string, got %v, want %v", testName, got, tc.expectString)
+		}
+		if tc.expectString == "Unknown" {
+			continue
+		}
+		parsed, err := ParsePermission(tc.expectString)
+		if err != nil {
+			t.Errorf("%s: failed to parse permission, got err %v", testName, err)
+		}
+		if parsed != tc.permission {
+			t.Errorf("%s: diff parsed permission, got %v, want %v", testName, parsed, tc.permission)
+		}
+	}
+
+	if _, err := ParsePermission("Unknown"); err == nil {
+		t.Errorf("expect error when parsing undefined permission")
+	}
+}
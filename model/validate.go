@@ -0,0 +1,539 @@
+package model
+
+import (
+	"encoding/hex"
+	"math/big"
+
+	"github.com/lino-network/lino-go/errors"
+	"github.com/lino-network/lino-go/utils"
+
+	crypto "github.com/tendermint/tendermint/crypto"
+)
+
+//
+// Account related messages
+//
+
+// ValidateBasic implements Msg.
+func (msg RegisterMsg) ValidateBasic() error {
+	if err := checkUsername("referrer", msg.Referrer); err != nil {
+		return err
+	}
+	if err := checkUsername("new username", msg.NewUser); err != nil {
+		return err
+	}
+	if err := checkAmount("register fee", msg.RegisterFee); err != nil {
+		return err
+	}
+	return checkPubKeys(msg.NewResetPubKey, msg.NewTransactionPubKey, msg.NewAppPubKey)
+}
+
+// ValidateBasic implements Msg.
+func (msg FollowMsg) ValidateBasic() error {
+	if err := checkUsername("follower", msg.Follower); err != nil {
+		return err
+	}
+	return checkUsername("followee", msg.Followee)
+}
+
+// ValidateBasic implements Msg.
+func (msg UnfollowMsg) ValidateBasic() error {
+	if err := checkUsername("follower", msg.Follower); err != nil {
+		return err
+	}
+	return checkUsername("followee", msg.Followee)
+}
+
+// ValidateBasic implements Msg.
+func (msg ClaimMsg) ValidateBasic() error {
+	return checkUsername("username", msg.Username)
+}
+
+// ValidateBasic implements Msg.
+func (msg RecoverMsg) ValidateBasic() error {
+	if err := checkUsername("username", msg.Username); err != nil {
+		return err
+	}
+	return checkPubKeys(msg.NewResetPubKey, msg.NewTransactionPubKey, msg.NewAppPubKey)
+}
+
+// ValidateBasic implements Msg.
+func (msg TransferMsg) ValidateBasic() error {
+	if err := checkUsername("sender", msg.Sender); err != nil {
+		return err
+	}
+	if err := checkUsername("receiver", msg.Receiver); err != nil {
+		return err
+	}
+	if err := checkAmount("amount", msg.Amount); err != nil {
+		return err
+	}
+	return checkMaxLength("memo", msg.Memo, MaximumMemoLength)
+}
+
+// ValidateBasic returns an InvalidArg error if not exactly one of Username
+// and Address is set or the amount is invalid.
+func (output Output) ValidateBasic() error {
+	switch {
+	case output.Username != "" && output.Address != "":
+		return errors.InvalidArg("output has both username and address")
+	case output.Username != "":
+		if err := checkUsername("username", output.Username); err != nil {
+			return err
+		}
+	case output.Address != "":
+		if addr, err := hex.DecodeString(output.Address); err != nil || len(addr) != crypto.AddressSize {
+			return errors.InvalidArgf("invalid address: %v", output.Address)
+		}
+	default:
+		return errors.InvalidArg("output has neither username nor address")
+	}
+	return checkAmount("amount", output.Amount)
+}
+
+// ValidateBasic implements Msg.
+func (msg UpdateAccountMsg) ValidateBasic() error {
+	if err := checkUsername("username", msg.Username); err != nil {
+		return err
+	}
+	return checkMaxLength("json meta", msg.JSONMeta, MaximumJSONMetaLength)
+}
+
+//
+// Post related messages
+//
+
+// ValidateBasic implements Msg.
+func (msg CreatePostMsg) ValidateBasic() error {
+	if err := checkPermlink(msg.Author, msg.PostID); err != nil {
+		return err
+	}
+	if err := checkPostContent(msg.Title, msg.Content, msg.Links); err != nil {
+		return err
+	}
+
+	splitRate, ok := new(big.Rat).SetString(msg.RedistributionSplitRate)
+	if !ok || splitRate.Sign() < 0 || splitRate.Cmp(big.NewRat(1, 1)) > 0 {
+		return errors.InvalidArgf("invalid redistribution split rate: %v", msg.RedistributionSplitRate)
+	}
+	return nil
+}
+
+// ValidateBasic implements Msg.
+func (msg UpdatePostMsg) ValidateBasic() error {
+	if err := checkPermlink(msg.Author, msg.PostID); err != nil {
+		return err
+	}
+	return checkPostContent(msg.Title, msg.Content, msg.Links)
+}
+
+// ValidateBasic implements Msg.
+func (msg DeletePostMsg) ValidateBasic() error {
+	return checkPermlink(msg.Author, msg.PostID)
+}
+
+// ValidateBasic implements Msg.
+func (msg DonateMsg) ValidateBasic() error {
+	if err := checkUsername("username", msg.Username); err != nil {
+		return err
+	}
+	if err := checkPermlink(msg.Author, msg.PostID); err != nil {
+		return err
+	}
+	if err := checkAmount("amount", msg.Amount); err != nil {
+		return err
+	}
+	return checkMaxLength("memo", msg.Memo, MaximumMemoLength)
+}
+
+// ValidateBasic implements Msg.
+func (msg ViewMsg) ValidateBasic() error {
+	if err := checkUsername("username", msg.Username); err != nil {
+		return err
+	}
+	return checkPermlink(msg.Author, msg.PostID)
+}
+
+// ValidateBasic implements Msg.
+func (msg ReportOrUpvoteMsg) ValidateBasic() error {
+	if err := checkUsername("username", msg.Username); err != nil {
+		return err
+	}
+	return checkPermlink(msg.Author, msg.PostID)
+}
+
+// PostLimits are the limits Lino blockchain enforces on posts. The lengths are
+// fixed by the blockchain, only PostIntervalSec comes from PostParam.
+type PostLimits struct {
+	MaxPostIDLength         int   `json:"max_post_id_length"`
+	MaxTitleLength          int   `json:"max_title_length"`
+	MaxContentLength        int   `json:"max_content_length"`
+	MaxNumOfLinks           int   `json:"max_num_of_links"`
+	MaxLinkIdentifierLength int   `json:"max_link_identifier_length"`
+	MaxLinkURLLength        int   `json:"max_link_url_length"`
+	PostIntervalSec         int64 `json:"post_interval_sec"`
+}
+
+// DefaultPostLimits returns the length limits of posts, PostIntervalSec is left 0.
+func DefaultPostLimits() PostLimits {
+	return PostLimits{
+		MaxPostIDLength:         MaximumLengthOfPostID,
+		MaxTitleLength:          MaxPostTitleLength,
+		MaxContentLength:        MaxPostContentLength,
+		MaxNumOfLinks:           MaximumNumOfLinks,
+		MaxLinkIdentifierLength: MaximumLinkIdentifier,
+		MaxLinkURLLength:        MaximumLinkURL,
+	}
+}
+
+// ValidatePost returns an InvalidArg error if the post id is empty or the
+// post id, title, content or links exceed the limits.
+func (limits PostLimits) ValidatePost(postID, title, content string, links []IDToURLMapping) error {
+	if postID == "" {
+		return errors.InvalidArg("post id is empty")
+	}
+	if err := checkMaxLength("post id", postID, limits.MaxPostIDLength); err != nil {
+		return err
+	}
+	return limits.validateContent(title, content, links)
+}
+
+func (limits PostLimits) validateContent(title, content string, links []IDToURLMapping) error {
+	if err := checkMaxLength("title", title, limits.MaxTitleLength); err != nil {
+		return err
+	}
+	if err := checkMaxLength("content", content, limits.MaxContentLength); err != nil {
+		return err
+	}
+	if len(links) > limits.MaxNumOfLinks {
+		return errors.InvalidArgf("number of links exceeds %v", limits.MaxNumOfLinks)
+	}
+	for _, link := range links {
+		if err := checkMaxLength("link identifier", link.Identifier, limits.MaxLinkIdentifierLength); err != nil {
+			return err
+		}
+		if err := checkMaxLength("link url", link.URL, limits.MaxLinkURLLength); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//
+// Validator related messages
+//
+
+// ValidateBasic implements Msg.
+func (msg ValidatorDepositMsg) ValidateBasic() error {
+	if err := checkUsername("username", msg.Username); err != nil {
+		return err
+	}
+	if err := checkAmount("deposit", msg.Deposit); err != nil {
+		return err
+	}
+	return checkPubKeys(msg.ValPubKey)
+}
+
+// ValidateBasic implements Msg.
+func (msg ValidatorWithdrawMsg) ValidateBasic() error {
+	if err := checkUsername("username", msg.Username); err != nil {
+		return err
+	}
+	return checkAmount("amount", msg.Amount)
+}
+
+// ValidateBasic implements Msg.
+func (msg ValidatorRevokeMsg) ValidateBasic() error {
+	return checkUsername("username", msg.Username)
+}
+
+//
+// Vote related messages
+//
+
+// ValidateBasic implements Msg.
+func (msg StakeInMsg) ValidateBasic() error {
+	if err := checkUsername("username", msg.Username); err != nil {
+		return err
+	}
+	return checkAmount("deposit", msg.Deposit)
+}
+
+// ValidateBasic implements Msg.
+func (msg StakeOutMsg) ValidateBasic() error {
+	if err := checkUsername("username", msg.Username); err != nil {
+		return err
+	}
+	return checkAmount("amount", msg.Amount)
+}
+
+// ValidateBasic implements Msg.
+func (msg DelegateMsg) ValidateBasic() error {
+	if err := checkUsername("delegator", msg.Delegator); err != nil {
+		return err
+	}
+	if err := checkUsername("voter", msg.Voter); err != nil {
+		return err
+	}
+	return checkAmount("amount", msg.Amount)
+}
+
+// ValidateBasic implements Msg.
+func (msg DelegatorWithdrawMsg) ValidateBasic() error {
+	if err := checkUsername("delegator", msg.Delegator); err != nil {
+		return err
+	}
+	if err := checkUsername("voter", msg.Voter); err != nil {
+		return err
+	}
+	return checkAmount("amount", msg.Amount)
+}
+
+// ValidateBasic implements Msg.
+func (msg ClaimInterestMsg) ValidateBasic() error {
+	return checkUsername("username", msg.Username)
+}
+
+//
+// developer related messages
+//
+
+// ValidateBasic implements Msg.
+func (msg DeveloperRegisterMsg) ValidateBasic() error {
+	if err := checkUsername("username", msg.Username); err != nil {
+		return err
+	}
+	if err := checkAmount("deposit", msg.Deposit); err != nil {
+		return err
+	}
+	return checkDeveloperInfo(msg.Website, msg.Description, msg.AppMetaData)
+}
+
+// ValidateBasic implements Msg.
+func (msg DeveloperUpdateMsg) ValidateBasic() error {
+	if err := checkUsername("username", msg.Username); err != nil {
+		return err
+	}
+	return checkDeveloperInfo(msg.Website, msg.Description, msg.AppMetaData)
+}
+
+// ValidateBasic implements Msg.
+func (msg DeveloperRevokeMsg) ValidateBasic() error {
+	return checkUsername("username", msg.Username)
+}
+
+// ValidateBasic implements Msg.
+func (msg GrantPermissionMsg) ValidateBasic() error {
+	if err := checkUsername("username", msg.Username); err != nil {
+		return err
+	}
+	if err := checkUsername("authorized app", msg.AuthorizedApp); err != nil {
+		return err
+	}
+	if msg.ValidityPeriodSec <= 0 {
+		return errors.InvalidArgf("invalid validity period: %v", msg.ValidityPeriodSec)
+	}
+	switch msg.GrantLevel {
+	case UnknownPermission, TransactionPermission, ResetPermission:
+		return errors.InvalidArgf("permission %v can't be granted", msg.GrantLevel)
+	}
+	return nil
+}
+
+// ValidateBasic implements Msg.
+func (msg RevokePermissionMsg) ValidateBasic() error {
+	if err := checkUsername("username", msg.Username); err != nil {
+		return err
+	}
+	return checkPubKeys(msg.PubKey)
+}
+
+// ValidateBasic implements Msg.
+func (msg PreAuthorizationMsg) ValidateBasic() error {
+	if err := checkUsername("username", msg.Username); err != nil {
+		return err
+	}
+	if err := checkUsername("authorized app", msg.AuthorizedApp); err != nil {
+		return err
+	}
+	if msg.ValidityPeriodSec <= 0 {
+		return errors.InvalidArgf("invalid validity period: %v", msg.ValidityPeriodSec)
+	}
+	return checkAmount("amount", msg.Amount)
+}
+
+//
+// infra related messages
+//
+
+// ValidateBasic implements Msg.
+func (msg ProviderReportMsg) ValidateBasic() error {
+	if err := checkUsername("username", msg.Username); err != nil {
+		return err
+	}
+	if msg.Usage < 0 {
+		return errors.InvalidArgf("invalid usage: %v", msg.Usage)
+	}
+	return nil
+}
+
+//
+// proposal related messages
+//
+
+// ValidateBasic implements Msg.
+func (msg DeletePostContentMsg) ValidateBasic() error {
+	if err := checkUsername("creator", msg.Creator); err != nil {
+		return err
+	}
+	if msg.Permlink == "" {
+		return errors.InvalidArg("permlink is empty")
+	}
+	return checkReason(msg.Reason)
+}
+
+// ValidateBasic implements Msg.
+func (msg UpgradeProtocolMsg) ValidateBasic() error {
+	if err := checkUsername("creator", msg.Creator); err != nil {
+		return err
+	}
+	if msg.Link == "" {
+		return errors.InvalidArg("link is empty")
+	}
+	return checkReason(msg.Reason)
+}
+
+// ValidateBasic implements Msg.
+func (msg ChangeGlobalAllocationParamMsg) ValidateBasic() error {
+	return checkChangeParam(msg.Creator, msg.Reason)
+}
+
+// ValidateBasic implements Msg.
+func (msg ChangeEvaluateOfContentValueParamMsg) ValidateBasic() error {
+	return checkChangeParam(msg.Creator, msg.Reason)
+}
+
+// ValidateBasic implements Msg.
+func (msg ChangeInfraInternalAllocationParamMsg) ValidateBasic() error {
+	return checkChangeParam(msg.Creator, msg.Reason)
+}
+
+// ValidateBasic implements Msg.
+func (msg ChangeVoteParamMsg) ValidateBasic() error {
+	return checkChangeParam(msg.Creator, msg.Reason)
+}
+
+// ValidateBasic implements Msg.
+func (msg ChangeProposalParamMsg) ValidateBasic() error {
+	return checkChangeParam(msg.Creator, msg.Reason)
+}
+
+// ValidateBasic implements Msg.
+func (msg ChangeDeveloperParamMsg) ValidateBasic() error {
+	return checkChangeParam(msg.Creator, msg.Reason)
+}
+
+// ValidateBasic implements Msg.
+func (msg ChangeValidatorParamMsg) ValidateBasic() error {
+	return checkChangeParam(msg.Creator, msg.Reason)
+}
+
+// ValidateBasic implements Msg.
+func (msg ChangeBandwidthParamMsg) ValidateBasic() error {
+	return checkChangeParam(msg.Creator, msg.Reason)
+}
+
+// ValidateBasic implements Msg.
+func (msg ChangeAccountParamMsg) ValidateBasic() error {
+	return checkChangeParam(msg.Creator, msg.Reason)
+}
+
+// ValidateBasic implements Msg.
+func (msg ChangePostParamMsg) ValidateBasic() error {
+	return checkChangeParam(msg.Creator, msg.Reason)
+}
+
+// ValidateBasic implements Msg.
+func (msg VoteProposalMsg) ValidateBasic() error {
+	if err := checkUsername("voter", msg.Voter); err != nil {
+		return err
+	}
+	if msg.ProposalID == "" {
+		return errors.InvalidArg("proposal id is empty")
+	}
+	return nil
+}
+
+//
+// internal helper functions
+//
+
+func checkUsername(field, username string) error {
+	if len(username) < MinimumUsernameLength || len(username) > MaximumUsernameLength ||
+		!util.CheckUsername(username) {
+		return errors.InvalidArgf("invalid %v: %v", field, username)
+	}
+	return nil
+}
+
+func checkAmount(field, amount string) error {
+	num, ok := new(big.Rat).SetString(amount)
+	if !ok {
+		return errors.InvalidArgf("invalid %v: %v", field, amount)
+	}
+	if num.Cmp(LowerBoundRat) < 0 || num.Cmp(UpperBoundRat) > 0 {
+		return errors.InvalidArgf("%v out of range: %v", field, amount)
+	}
+	return nil
+}
+
+func checkMaxLength(field, value string, maxLength int) error {
+	if len(value) > maxLength {
+		return errors.InvalidArgf("%v exceeds max length %v", field, maxLength)
+	}
+	return nil
+}
+
+func checkPubKeys(pubKeys ...crypto.PubKey) error {
+	for _, pubKey := range pubKeys {
+		if pubKey == nil {
+			return errors.InvalidArg("public key is empty")
+		}
+	}
+	return nil
+}
+
+func checkPermlink(author, postID string) error {
+	if err := checkUsername("author", author); err != nil {
+		return err
+	}
+	if postID == "" {
+		return errors.InvalidArg("post id is empty")
+	}
+	return checkMaxLength("post id", postID, MaximumLengthOfPostID)
+}
+
+func checkPostContent(title, content string, links []IDToURLMapping) error {
+	return DefaultPostLimits().validateContent(title, content, links)
+}
+
+func checkDeveloperInfo(website, description, appMetaData string) error {
+	if err := checkMaxLength("website", website, MaximumLengthOfDeveloperWebsite); err != nil {
+		return err
+	}
+	if err := checkMaxLength("description", description, MaximumLengthOfDeveloperDescription); err != nil {
+		return err
+	}
+	return checkMaxLength("app meta data", appMetaData, MaximumLengthOfAppMetadata)
+}
+
+func checkReason(reason string) error {
+	return checkMaxLength("reason", reason, MaximumLengthOfProposalReason)
+}
+
+func checkChangeParam(creator, reason string) error {
+	if err := checkUsername("creator", creator); err != nil {
+		return err
+	}
+	return checkReason(reason)
+}
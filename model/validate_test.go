@@ -0,0 +1,191 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lino-network/lino-go/errors"
+)
+
+func TestValidateBasic(t *testing.T) {
+	testCases := map[string]struct {
+		msg       Msg
+		expectErr bool
+	}{
+		"valid transfer": {
+			msg:       TransferMsg{Sender: "alice", Receiver: "bob1", Amount: "0.00001", Memo: "memo"},
+			expectErr: false,
+		},
+		"transfer with short receiver": {
+			msg:       TransferMsg{Sender: "alice", Receiver: "bo", Amount: "1"},
+			expectErr: true,
+		},
+		"transfer with illegal receiver": {
+			msg:       TransferMsg{Sender: "alice", Receiver: "Bad_User", Amount: "1"},
+			expectErr: true,
+		},
+		"transfer with invalid amount": {
+			msg:       TransferMsg{Sender: "alice", Receiver: "bob1", Amount: "1a"},
+			expectErr: true,
+		},
+		"transfer with amount underflow": {
+			msg:       TransferMsg{Sender: "alice", Receiver: "bob1", Amount: "0.000001"},
+			expectErr: true,
+		},
+		"transfer with too long memo": {
+			msg:       TransferMsg{Sender: "alice", Receiver: "bob1", Amount: "1", Memo: strings.Repeat("m", MaximumMemoLength+1)},
+			expectErr: true,
+		},
+		"valid post": {
+			msg:       CreatePostMsg{Author: "alice", PostID: "post1", Title: "title", RedistributionSplitRate: "0.5"},
+			expectErr: false,
+		},
+		"post without post id": {
+			msg:       CreatePostMsg{Author: "alice", Title: "title", RedistributionSplitRate: "0"},
+			expectErr: true,
+		},
+		"post with invalid split rate": {
+			msg:       CreatePostMsg{Author: "alice", PostID: "post1", RedistributionSplitRate: "1.5"},
+			expectErr: true,
+		},
+		"register with illegal new username": {
+			msg:       RegisterMsg{Referrer: "alice", NewUser: "Bob", RegisterFee: "1"},
+			expectErr: true,
+		},
+	}
+
+	for testName, tc := range testCases {
+		err := tc.msg.ValidateBasic()
+		if tc.expectErr && err == nil {
+			t.Errorf("%s: expect error, got nil", testName)
+		}
+		if !tc.expectErr && err != nil {
+			t.Errorf("%s: expect no error, got %v", testName, err)
+		}
+	}
+}
+
+func TestPostLimitsValidatePost(t *testing.T) {
+	limits := DefaultPostLimits()
+	testCases := map[string]struct {
+		postID    string
+		title     string
+		content   string
+		links     []IDToURLMapping
+		expectErr bool
+	}{
+		"valid post": {
+			postID: "post1", title: "title", content: "content",
+			links: []IDToURLMapping{{Identifier: "web", URL: "https://lino.network"}},
+		},
+		"empty post id": {
+			title: "title", expectErr: true,
+		},
+		"too long title": {
+			postID: "post1", title: strings.Repeat("t", MaxPostTitleLength+1), expectErr: true,
+		},
+		"too long content": {
+			postID: "post1", content: strings.Repeat("c", MaxPostContentLength+1), expectErr: true,
+		},
+		"too many links": {
+			postID: "post1", links: make([]IDToURLMapping, MaximumNumOfLinks+1), expectErr: true,
+		},
+	}
+
+	for testName, tc := range testCases {
+		err := limits.ValidatePost(tc.postID, tc.title, tc.content, tc.links)
+		if tc.expectErr && err == nil {
+			t.Errorf("%s: expect error, got nil", testName)
+		}
+		if !tc.expectErr && err != nil {
+			t.Errorf("%s: expect no error, got %v", testName, err)
+		}
+	}
+}
+
+func TestOutputValidateBasic(t *testing.T) {
+	address := strings.Repeat("ab", 20)
+	testCases := map[string]struct {
+		output    Output
+		expectErr bool
+	}{
+		"valid username output": {
+			output: Output{Username: "alice", Amount: "1"},
+		},
+		"valid address output": {
+			output: Output{Address: address, Amount: "1"},
+		},
+		"both username and address": {
+			output:    Output{Username: "alice", Address: address, Amount: "1"},
+			expectErr: true,
+		},
+		"neither username nor address": {
+			output:    Output{Amount: "1"},
+			expectErr: true,
+		},
+		"invalid address": {
+			output:    Output{Address: "xyz", Amount: "1"},
+			expectErr: true,
+		},
+		"invalid amount": {
+			output:    Output{Username: "alice", Amount: "1a"},
+			expectErr: true,
+		},
+	}
+
+	for testName, tc := range testCases {
+		err := tc.output.ValidateBasic()
+		if tc.expectErr && err == nil {
+			t.Errorf("%s: expect error, got nil", testName)
+		}
+		if !tc.expectErr && err != nil {
+			t.Errorf("%s: expect no error, got %v", testName, err)
+		}
+	}
+}
+
+func TestValidateMsgs(t *testing.T) {
+	valid := TransferMsg{Sender: "alice", Receiver: "bob1", Amount: "1"}
+	testCases := map[string]struct {
+		msgs          []Msg
+		expectInvalid []string
+	}{
+		"all valid": {
+			msgs: []Msg{valid, FollowMsg{Follower: "alice", Followee: "bob1"}},
+		},
+		"empty batch": {},
+		"some invalid": {
+			msgs: []Msg{
+				valid,
+				TransferMsg{Sender: "alice", Receiver: "bo", Amount: "1"},
+				valid,
+				FollowMsg{Follower: "alice"},
+				nil,
+			},
+			expectInvalid: []string{"msg 1 (model.TransferMsg)", "msg 3 (model.FollowMsg)", "msg 4: nil"},
+		},
+	}
+
+	for testName, tc := range testCases {
+		err := ValidateMsgs(tc.msgs)
+		if len(tc.expectInvalid) == 0 {
+			if err != nil {
+				t.Errorf("%s: expect no error, got %v", testName, err)
+			}
+			continue
+		}
+		vErr, ok := err.(errors.Error)
+		if !ok || vErr.CodeType() != errors.CodeInvalidArg {
+			t.Errorf("%s: diff err, got %v", testName, err)
+			continue
+		}
+		for _, invalid := range tc.expectInvalid {
+			if !strings.Contains(err.Error(), invalid) {
+				t.Errorf("%s: err %v doesn't list %v", testName, err, invalid)
+			}
+		}
+		if strings.Contains(err.Error(), "msg 0") || strings.Contains(err.Error(), "msg 2") {
+			t.Errorf("%s: err %v lists a valid msg", testName, err)
+		}
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"encoding/hex"
 	"math"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/lino-network/lino-go/errors"
 	"github.com/lino-network/lino-go/model"
@@ -13,7 +15,14 @@ import (
 	"github.com/tendermint/tendermint/crypto"
 )
 
-// GetAccountInfo returns account info for a specific user.
+// Bounds of the interval between two checks of WaitForAccount.
+const (
+	minAccountPollInterval = 500 * time.Millisecond
+	maxAccountPollInterval = 5 * time.Second
+)
+
+// GetAccountInfo returns account info for a specific user,
+// with the address derived from the transaction key.
 func (query *Query) GetAccountInfo(ctx context.Context, username string) (*model.AccountInfo, error) {
 	resp, err := query.transport.Query(ctx, getAccountInfoKey(username), AccountKVStoreKey)
 	if err != nil {
@@ -23,9 +32,157 @@ func (query *Query) GetAccountInfo(ctx context.Context, username string) (*model
 	if err := query.transport.Cdc.UnmarshalJSON(resp, info); err != nil {
 		return nil, err
 	}
+	info.FillAddress()
 	return info, nil
 }
 
+// GetAccount returns the info and the bank of a user in one struct,
+// both parts are queried concurrently.
+func (query *Query) GetAccount(ctx context.Context, username string) (*model.Account, error) {
+	var info *model.AccountInfo
+	var bank *model.AccountBank
+	var infoErr, bankErr error
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		info, infoErr = query.GetAccountInfo(ctx, username)
+	}()
+	go func() {
+		defer wg.Done()
+		bank, bankErr = query.GetAccountBank(ctx, username)
+	}()
+	wg.Wait()
+
+	if infoErr != nil {
+		if isEmptyResponse(infoErr) {
+			return nil, errors.EmptyResponsef("account info of %v not found", username).AddCause(infoErr)
+		}
+		return nil, infoErr
+	}
+	if bankErr != nil {
+		if isEmptyResponse(bankErr) {
+			return nil, errors.EmptyResponsef("account bank of %v not found", username).AddCause(bankErr)
+		}
+		return nil, bankErr
+	}
+
+	return &model.Account{
+		Username:        info.Username,
+		CreatedAt:       info.CreatedAt,
+		ResetKey:        info.ResetKey,
+		TransactionKey:  info.TransactionKey,
+		AppKey:          info.AppKey,
+		Saving:          bank.Saving,
+		CoinDay:         bank.CoinDay,
+		FrozenMoneyList: bank.FrozenMoneyList,
+		NumOfTx:         bank.NumOfTx,
+		NumOfReward:     bank.NumOfReward,
+	}, nil
+}
+
+// GetTotalStake returns the coin a user has locked as a voter, a validator
+// and a developer, and their sum. The roles are read by GetUserStakeRoles, a
+// role the user doesn't have counts as zero.
+func (query *Query) GetTotalStake(ctx context.Context, username string) (*model.Stake, error) {
+	stake := &model.Stake{
+		VoterDeposit:     model.NewCoinFromInt64(0),
+		ValidatorDeposit: model.NewCoinFromInt64(0),
+		DeveloperDeposit: model.NewCoinFromInt64(0),
+	}
+
+	roles, err := query.GetUserStakeRoles(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if roles.Voter != nil {
+		stake.VoterDeposit = roles.Voter.LinoStake
+	}
+	if roles.Validator != nil {
+		stake.ValidatorDeposit = roles.Validator.Deposit
+	}
+	if roles.Developer != nil {
+		stake.DeveloperDeposit = roles.Developer.Deposit
+	}
+
+	stake.Total = stake.VoterDeposit.Plus(stake.ValidatorDeposit).Plus(stake.DeveloperDeposit)
+	return stake, nil
+}
+
+// GetUserStakeRoles returns the voter, validator and developer records of a user,
+// queried concurrently. A role the user doesn't have is left nil, it is not an error.
+func (query *Query) GetUserStakeRoles(ctx context.Context, username string) (*model.StakeRoles, error) {
+	roles := &model.StakeRoles{}
+	var voterErr, validatorErr, developerErr error
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		roles.Voter, voterErr = query.GetVoter(ctx, username)
+	}()
+	go func() {
+		defer wg.Done()
+		roles.Validator, validatorErr = query.GetValidator(ctx, username)
+	}()
+	go func() {
+		defer wg.Done()
+		roles.Developer, developerErr = query.GetDeveloper(ctx, username)
+	}()
+	wg.Wait()
+
+	for _, err := range []error{voterErr, validatorErr, developerErr} {
+		if err != nil && !isEmptyResponse(err) {
+			return nil, err
+		}
+	}
+	if voterErr != nil {
+		roles.Voter = nil
+	}
+	if validatorErr != nil {
+		roles.Validator = nil
+	}
+	if developerErr != nil {
+		roles.Developer = nil
+	}
+	return roles, nil
+}
+
+// DoesUsernameExist returns true if the account of username has been registered.
+func (query *Query) DoesUsernameExist(ctx context.Context, username string) (bool, error) {
+	_, err := query.transport.Query(ctx, getAccountInfoKey(username), AccountKVStoreKey)
+	if err != nil {
+		if isEmptyResponse(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// WaitForAccount polls until the account of username exists, e.g. right after
+// it is registered, with an interval growing from minAccountPollInterval to
+// maxAccountPollInterval. It returns a Timeout error if ctx is done first.
+func (query *Query) WaitForAccount(ctx context.Context, username string) error {
+	interval := minAccountPollInterval
+	for {
+		exist, err := query.DoesUsernameExist(ctx, username)
+		if err == nil && exist {
+			return nil
+		}
+
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return errors.Timeoutf("wait for account %v timeout", username).AddCause(ctx.Err())
+		}
+		if interval *= 2; interval > maxAccountPollInterval {
+			interval = maxAccountPollInterval
+		}
+	}
+}
+
 // GetTransactionPubKey returns string format transaction public key.
 func (query *Query) GetTransactionPubKey(ctx context.Context, username string) (string, error) {
 	resp, err := query.transport.Query(ctx, getAccountInfoKey(username), AccountKVStoreKey)
@@ -110,6 +267,24 @@ func (query *Query) GetAccountBank(ctx context.Context, username string) (*model
 	return bank, nil
 }
 
+// GetAccountBankOrZero is GetAccountBank for balance display, an unregistered
+// user gets an empty bank with zero saving and coin day instead of an error.
+// registered is false in that case.
+func (query *Query) GetAccountBankOrZero(ctx context.Context, username string) (bank *model.AccountBank, registered bool, err error) {
+	bank, err = query.GetAccountBank(ctx, username)
+	if err == nil {
+		return bank, true, nil
+	}
+	if !isEmptyResponse(err) {
+		return nil, false, err
+	}
+	return &model.AccountBank{
+		Saving:          model.NewCoinFromInt64(0),
+		CoinDay:         model.NewCoinFromInt64(0),
+		FrozenMoneyList: []model.FrozenMoney{},
+	}, false, nil
+}
+
 // GetAccountMeta returns account meta info for a specific user.
 func (query *Query) GetAccountMeta(ctx context.Context, username string) (*model.AccountMeta, error) {
 	resp, err := query.transport.Query(ctx, getAccountMetaKey(username), AccountKVStoreKey)
@@ -123,6 +298,76 @@ func (query *Query) GetAccountMeta(ctx context.Context, username string) (*model
 	return meta, nil
 }
 
+// GetAccountStateAtHeight returns the bank and the meta of a user at a
+// certain height, so that both are read from the same state.
+func (query *Query) GetAccountStateAtHeight(ctx context.Context, username string, height int64) (*model.AccountState, error) {
+	bankResp, err := query.transport.QueryAtHeight(ctx, getAccountBankKey(username), AccountKVStoreKey, height)
+	if err != nil {
+		return nil, err
+	}
+	bank := new(model.AccountBank)
+	if err := query.transport.Cdc.UnmarshalJSON(bankResp, bank); err != nil {
+		return nil, err
+	}
+
+	metaResp, err := query.transport.QueryAtHeight(ctx, getAccountMetaKey(username), AccountKVStoreKey, height)
+	if err != nil {
+		return nil, err
+	}
+	meta := new(model.AccountMeta)
+	if err := query.transport.Cdc.UnmarshalJSON(metaResp, meta); err != nil {
+		return nil, err
+	}
+
+	return &model.AccountState{
+		Height: height,
+		Bank:   bank,
+		Meta:   meta,
+	}, nil
+}
+
+// GetTransactionCapacity returns the transaction capacity of a user at the latest
+// block time, recovered from the capacity recorded at the last activity.
+func (query *Query) GetTransactionCapacity(ctx context.Context, username string) (model.Coin, error) {
+	param, err := query.GetBandwidthParam(ctx)
+	if err != nil {
+		return model.Coin{}, err
+	}
+	return query.getTransactionCapacity(ctx, username, param)
+}
+
+// CanAffordTransaction returns true if the current transaction capacity of a user
+// covers one more transaction, see model.AccountMeta.CanAffordTx.
+func (query *Query) CanAffordTransaction(ctx context.Context, username string) (bool, error) {
+	param, err := query.GetBandwidthParam(ctx)
+	if err != nil {
+		return false, err
+	}
+	capacity, err := query.getTransactionCapacity(ctx, username, param)
+	if err != nil {
+		return false, err
+	}
+	return capacity.IsGTE(param.CapacityUsagePerTransaction), nil
+}
+
+// getTransactionCapacity returns the transaction capacity of a user under param.
+func (query *Query) getTransactionCapacity(ctx context.Context, username string,
+	param *model.BandwidthParam) (model.Coin, error) {
+	meta, err := query.GetAccountMeta(ctx, username)
+	if err != nil {
+		return model.Coin{}, err
+	}
+	bank, err := query.GetAccountBank(ctx, username)
+	if err != nil {
+		return model.Coin{}, err
+	}
+	status, err := query.transport.QueryBlockStatus(ctx)
+	if err != nil {
+		return model.Coin{}, errors.QueryFailf("GetTransactionCapacity: failed to get block status").AddCause(err)
+	}
+	return meta.CurrentCapacity(bank.CoinDay, *param, status.SyncInfo.LatestBlockTime.Unix()), nil
+}
+
 // GetSeqNumber returns the next sequence number of a user which should
 // be used for broadcast.
 func (query *Query) GetSeqNumber(ctx context.Context, username string) (int64, error) {
@@ -162,6 +407,32 @@ func (query *Query) GetAllBalanceHistory(ctx context.Context, username string) (
 	return allBalanceHistory, nil
 }
 
+// GetAccountBalanceHistory returns all transaction history related to a user's
+// account balance in chronological order, i.e. an account statement. Each detail
+// carries its DetailType, see DetailType.String for a label, and the balance after it.
+func (query *Query) GetAccountBalanceHistory(ctx context.Context, username string) (*model.BalanceHistory, error) {
+	accountBank, err := query.GetAccountBank(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	statement := new(model.BalanceHistory)
+	if accountBank.NumOfTx == 0 {
+		return statement, nil
+	}
+
+	bucketSlot := (accountBank.NumOfTx - 1) / 100
+	for i := int64(0); i <= bucketSlot; i++ {
+		balanceHistory, err := query.GetBalanceHistory(ctx, username, i)
+		if err != nil {
+			return nil, err
+		}
+		statement.Details = append(statement.Details, balanceHistory.Details...)
+	}
+
+	return statement, nil
+}
+
 // GetRecentBalanceHistory returns a certain number of recent transaction history
 // related to a user's account balance, in reverse-chronological order.
 func (query *Query) GetRecentBalanceHistory(ctx context.Context, username string, numHistory int64) (*model.BalanceHistory, error) {
@@ -263,6 +534,33 @@ func (query *Query) GetBalanceHistory(ctx context.Context, username string, inde
 	return balanceHistory, nil
 }
 
+// GetAllBalanceHistoryByDetailType returns all transaction history related to
+// a user's account balance whose detail type is one of detailTypes,
+// in reverse-chronological order. If no detail type is given, all history is returned.
+func (query *Query) GetAllBalanceHistoryByDetailType(
+	ctx context.Context, username string, detailTypes ...model.DetailType) (*model.BalanceHistory, error) {
+	allBalanceHistory, err := query.GetAllBalanceHistory(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if len(detailTypes) == 0 {
+		return allBalanceHistory, nil
+	}
+
+	wanted := make(map[model.DetailType]bool, len(detailTypes))
+	for _, detailType := range detailTypes {
+		wanted[detailType] = true
+	}
+
+	filtered := new(model.BalanceHistory)
+	for _, detail := range allBalanceHistory.Details {
+		if wanted[detail.DetailType] {
+			filtered.Details = append(filtered.Details, detail)
+		}
+	}
+	return filtered, nil
+}
+
 // GetGrantPubKey returns the specific granted pubkey info of a user
 // that has given to the pubKey.
 func (query *Query) GetGrantPubKey(ctx context.Context, username string, pubKeyHex string) (*model.GrantPubKey, error) {
@@ -297,6 +595,45 @@ func (query *Query) GetReward(ctx context.Context, username string) (*model.Rewa
 	return reward, nil
 }
 
+// GetRewardsForUsers returns the reward of each user, keyed by username. The
+// rewards are queried concurrently with the bound of transport.QueryBatch, it
+// fails if any of them can't be read.
+func (query *Query) GetRewardsForUsers(ctx context.Context, usernames []string) (map[string]*model.Reward, error) {
+	requests := make([]transport.QueryRequest, 0, len(usernames))
+	for _, username := range usernames {
+		requests = append(requests, transport.QueryRequest{
+			Key:       getRewardKey(username),
+			StoreName: AccountKVStoreKey,
+		})
+	}
+	responses, err := query.transport.QueryBatch(ctx, requests)
+	if err != nil {
+		return nil, err
+	}
+
+	usernameToRewardMap := make(map[string]*model.Reward, len(usernames))
+	for i, resp := range responses {
+		if resp.Err != nil {
+			return nil, errors.QueryFailf("GetRewardsForUsers: failed to get reward of %v", usernames[i]).AddCause(resp.Err)
+		}
+		reward := new(model.Reward)
+		if err := query.transport.Cdc.UnmarshalJSON(resp.Value, reward); err != nil {
+			return nil, errors.DecodeFailedf("GetRewardsForUsers: failed to decode reward of %v", usernames[i]).AddCause(err).AddRawData(resp.Value)
+		}
+		usernameToRewardMap[usernames[i]] = reward
+	}
+	return usernameToRewardMap, nil
+}
+
+// GetClaimableReward returns the reward a Claim of a user pays out now, see model.Reward.Claimable.
+func (query *Query) GetClaimableReward(ctx context.Context, username string) (model.Coin, error) {
+	reward, err := query.GetReward(ctx, username)
+	if err != nil {
+		return model.Coin{}, err
+	}
+	return reward.Claimable(), nil
+}
+
 // GetRewardAtHeight returns rewards of a user at certain height.
 func (query *Query) GetRewardAtHeight(ctx context.Context, username string, height int64) (*model.Reward, error) {
 	resp, err := query.transport.QueryAtHeight(ctx, getRewardKey(username), AccountKVStoreKey, height)
@@ -512,6 +849,35 @@ func (query *Query) GetAllGrantPubKeys(ctx context.Context, username string) (ma
 	return pubKeyToGrantPubKeyMap, nil
 }
 
+// IterateAllAccounts calls fn with the info of every registered account in
+// the order of usernames. The subspace query of the node has no pagination,
+// so accounts are fetched with one query per first letter of the username, and
+// all accounts of that letter are loaded at once: memory is bounded only by
+// the largest first-letter bucket, not by a fixed page size. The iteration
+// stops at the first error returned by fn, which is returned as is.
+func (query *Query) IterateAllAccounts(ctx context.Context, fn func(*model.AccountInfo) error) error {
+	// a username always starts with a lowercase letter
+	for letter := byte('a'); letter <= 'z'; letter++ {
+		prefix := append(append([]byte{}, accountInfoSubstore...), letter)
+		resKVs, err := query.transport.QuerySubspace(ctx, prefix, AccountKVStoreKey)
+		if err != nil {
+			return err
+		}
+		for _, KV := range resKVs {
+			info := new(model.AccountInfo)
+			if err := query.transport.Cdc.UnmarshalJSON(KV.Value, info); err != nil {
+				return errors.DecodeFailedf("IterateAllAccounts: failed to decode account %v",
+					getSubstringAfterSubstore(KV.Key)).AddCause(err)
+			}
+			info.FillAddress()
+			if err := fn(info); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // GetAllRelationships returns all donation relationship of a user.
 func (query *Query) GetAllRelationships(ctx context.Context, username string) (map[string]*model.Relationship, error) {
 	resKVs, err := query.transport.QuerySubspace(ctx, getRelationshipPrefix(username), AccountKVStoreKey)
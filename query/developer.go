@@ -3,7 +3,9 @@ package query
 import (
 	"context"
 
+	"github.com/lino-network/lino-go/errors"
 	"github.com/lino-network/lino-go/model"
+	"github.com/lino-network/lino-go/transport"
 )
 
 // GetDeveloper returns a specific developer info from blockchain.
@@ -32,3 +34,77 @@ func (query *Query) GetDevelopers(ctx context.Context) (*model.DeveloperList, er
 	}
 	return developerList, nil
 }
+
+// GetDeveloperStatuses returns the records of all developers, including their
+// deposit and app consumption, in the order of the developer list.
+// The records are queried in one batch, the first error aborts the call.
+func (query *Query) GetDeveloperStatuses(ctx context.Context) ([]*model.Developer, error) {
+	developerList, err := query.GetDevelopers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	requests := make([]transport.QueryRequest, 0, len(developerList.AllDevelopers))
+	for _, developerName := range developerList.AllDevelopers {
+		requests = append(requests, transport.QueryRequest{
+			Key:       getDeveloperKey(developerName),
+			StoreName: DeveloperKVStoreKey,
+		})
+	}
+	responses, err := query.transport.QueryBatch(ctx, requests)
+	if err != nil {
+		return nil, err
+	}
+
+	developers := make([]*model.Developer, 0, len(responses))
+	for _, resp := range responses {
+		if resp.Err != nil {
+			return nil, resp.Err
+		}
+		developer := new(model.Developer)
+		if err := query.transport.Cdc.UnmarshalJSON(resp.Value, developer); err != nil {
+			return nil, err
+		}
+		developers = append(developers, developer)
+	}
+	return developers, nil
+}
+
+// GetDeveloperInflationEstimate estimates the developer inflation a developer would
+// get if the developer inflation pool were distributed now, which is the pool split
+// in proportion to the app consumption of all developers.
+func (query *Query) GetDeveloperInflationEstimate(ctx context.Context, username string) (*model.DeveloperInflationEstimate, error) {
+	developers, err := query.GetDeveloperStatuses(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := query.GetInflationPool(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	estimate := &model.DeveloperInflationEstimate{
+		Username:               username,
+		TotalConsumption:       model.NewCoinFromInt64(0),
+		DeveloperInflationPool: pool.DeveloperInflationPool,
+		EstimatedInflation:     model.NewCoinFromInt64(0),
+	}
+	found := false
+	for _, developer := range developers {
+		if developer.Username == username {
+			estimate.AppConsumption = developer.AppConsumption
+			found = true
+		}
+		estimate.TotalConsumption = estimate.TotalConsumption.Plus(developer.AppConsumption)
+	}
+	if !found {
+		return nil, errors.EmptyResponsef("developer %v not found", username)
+	}
+
+	if estimate.TotalConsumption.IsPositive() {
+		estimate.EstimatedInflation = model.Coin{
+			Amount: pool.DeveloperInflationPool.Amount.Mul(estimate.AppConsumption.Amount).Div(estimate.TotalConsumption.Amount),
+		}
+	}
+	return estimate, nil
+}
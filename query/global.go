@@ -0,0 +1,102 @@
+package query
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/lino-network/lino-go/model"
+)
+
+// GetInflationPool returns the inflation not yet distributed to each role.
+func (query *Query) GetInflationPool(ctx context.Context) (*model.InflationPool, error) {
+	resp, err := query.transport.Query(ctx, getInflationPoolKey(), GlobalKVStoreKey)
+	if err != nil {
+		return nil, err
+	}
+	pool := new(model.InflationPool)
+	if err := query.transport.Cdc.UnmarshalJSON(resp, pool); err != nil {
+		return nil, err
+	}
+	return pool, nil
+}
+
+// GetGlobalMeta returns the supply of LNO.
+func (query *Query) GetGlobalMeta(ctx context.Context) (*model.GlobalMeta, error) {
+	resp, err := query.transport.Query(ctx, getGlobalMetaKey(), GlobalKVStoreKey)
+	if err != nil {
+		return nil, err
+	}
+	meta := new(model.GlobalMeta)
+	if err := query.transport.Cdc.UnmarshalJSON(resp, meta); err != nil {
+		return nil, err
+	}
+	return meta, nil
+}
+
+// GetInflationRate returns the annual inflation and the APR of the oncall validators.
+// The annual inflation is LastYearTotalLinoCoin times GlobalGrowthRate, of which
+// ValidatorAllocation goes to the oncall validators in equal shares. The APR assumes
+// the params and the oncall validators stay the same for a year, and is over the
+// average deposit, so a validator with a larger deposit earns a lower rate.
+// Voters get no inflation, their reward comes from the friction of donations.
+func (query *Query) GetInflationRate(ctx context.Context) (*model.InflationRate, error) {
+	meta, err := query.GetGlobalMeta(ctx)
+	if err != nil {
+		return nil, err
+	}
+	param, err := query.GetGlobalAllocationParam(ctx)
+	if err != nil {
+		return nil, err
+	}
+	validatorList, err := query.GetAllValidators(ctx)
+	if err != nil {
+		return nil, err
+	}
+	deposits := make([]model.Coin, 0, len(validatorList.OncallValidators))
+	for _, username := range validatorList.OncallValidators {
+		validator, err := query.GetValidator(ctx, username)
+		if err != nil {
+			return nil, err
+		}
+		deposits = append(deposits, validator.Deposit)
+	}
+	return inflationRateOf(meta, param, deposits), nil
+}
+
+// inflationRateOf computes the inflation rate with the deposits of the oncall validators.
+func inflationRateOf(meta *model.GlobalMeta, param *model.GlobalAllocationParam, deposits []model.Coin) *model.InflationRate {
+	growthRate := ratOf(param.GlobalGrowthRate)
+	annual := new(big.Rat).Mul(new(big.Rat).SetInt(meta.LastYearTotalLinoCoin.Amount.BigInt()), growthRate)
+	validatorAnnual := new(big.Rat).Mul(annual, ratOf(param.ValidatorAllocation))
+
+	rate := &model.InflationRate{
+		AnnualInflation:          coinOfRat(annual),
+		ValidatorAnnualInflation: coinOfRat(validatorAnnual),
+		OncallValidators:         len(deposits),
+	}
+	rate.GrowthRate, _ = growthRate.Float64()
+
+	// the validators share the inflation equally, so the total deposit
+	// earns it all, which is the same as the average deposit earning a share
+	totalDeposit := new(big.Int)
+	for _, deposit := range deposits {
+		totalDeposit.Add(totalDeposit, deposit.Amount.BigInt())
+	}
+	if totalDeposit.Sign() > 0 {
+		rate.ValidatorAPR, _ = new(big.Rat).Quo(validatorAnnual, new(big.Rat).SetInt(totalDeposit)).Float64()
+	}
+	return rate
+}
+
+// ratOf returns the value of r, 0 if it is unset.
+func ratOf(r model.Rat) *big.Rat {
+	if r.Rat == nil {
+		return new(big.Rat)
+	}
+	return r.Rat
+}
+
+// coinOfRat returns the coin of r rounded down.
+func coinOfRat(r *big.Rat) model.Coin {
+	return model.NewCoinFromBigInt(new(big.Int).Quo(r.Num(), r.Denom()))
+}
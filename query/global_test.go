@@ -0,0 +1,55 @@
+package query
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/lino-network/lino-go/model"
+)
+
+func TestInflationRateOf(t *testing.T) {
+	meta := &model.GlobalMeta{
+		TotalLinoCoin:         model.NewCoinFromInt64(1100000),
+		LastYearTotalLinoCoin: model.NewCoinFromInt64(1000000),
+	}
+	param := &model.GlobalAllocationParam{
+		GlobalGrowthRate:    model.Rat{Rat: big.NewRat(98, 1000)},
+		ValidatorAllocation: model.Rat{Rat: big.NewRat(5, 100)},
+	}
+
+	testCases := map[string]struct {
+		deposits  []model.Coin
+		expectAPR float64
+	}{
+		"same deposits": {
+			deposits:  []model.Coin{model.NewCoinFromInt64(2450), model.NewCoinFromInt64(2450)},
+			expectAPR: 1,
+		},
+		"different deposits": {
+			deposits:  []model.Coin{model.NewCoinFromInt64(4900), model.NewCoinFromInt64(19600)},
+			expectAPR: 0.2,
+		},
+		"no oncall validator": {
+			expectAPR: 0,
+		},
+	}
+
+	for testName, tc := range testCases {
+		rate := inflationRateOf(meta, param, tc.deposits)
+		if rate.GrowthRate != 0.098 {
+			t.Errorf("%s: diff growth rate, got %v, want %v", testName, rate.GrowthRate, 0.098)
+		}
+		if !rate.AnnualInflation.IsEqual(model.NewCoinFromInt64(98000)) {
+			t.Errorf("%s: diff annual inflation, got %v, want %v", testName, rate.AnnualInflation, 98000)
+		}
+		if !rate.ValidatorAnnualInflation.IsEqual(model.NewCoinFromInt64(4900)) {
+			t.Errorf("%s: diff validator annual inflation, got %v, want %v", testName, rate.ValidatorAnnualInflation, 4900)
+		}
+		if rate.OncallValidators != len(tc.deposits) {
+			t.Errorf("%s: diff oncall validators, got %v, want %v", testName, rate.OncallValidators, len(tc.deposits))
+		}
+		if rate.ValidatorAPR != tc.expectAPR {
+			t.Errorf("%s: diff validator APR, got %v, want %v", testName, rate.ValidatorAPR, tc.expectAPR)
+		}
+	}
+}
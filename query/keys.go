@@ -5,6 +5,8 @@ import (
 	"encoding/hex"
 	"strconv"
 
+	"github.com/lino-network/lino-go/model"
+
 	crypto "github.com/tendermint/tendermint/crypto"
 )
 
@@ -25,7 +27,7 @@ const (
 var (
 	// KeySeparator is the separator of substore key
 	KeySeparator      = "/"
-	PermLinkSeparator = "#"
+	PermLinkSeparator = model.PermLinkSeparator
 
 	// account substore
 	accountInfoSubstore                = []byte{0x00}
@@ -67,6 +69,10 @@ var (
 	infraProviderSubstore     = []byte{0x00}
 	infraProviderListSubstore = []byte{0x01}
 
+	// global substore
+	globalMetaSubStore    = []byte{0x02}
+	inflationPoolSubStore = []byte{0x03}
+
 	// proposal substore
 	nextProposalIDSubstore  = []byte{0x00}
 	ongoingProposalSubStore = []byte{0x01}
@@ -174,7 +180,7 @@ func getRewardHistoryKey(me string, bucketSlot int64) []byte {
 // post related
 //
 func getPermlink(author string, postID string) string {
-	return string(author + PermLinkSeparator + postID)
+	return model.Permlink(author, postID)
 }
 
 func getUserPostInfoPrefix(me string) []byte {
@@ -282,6 +288,17 @@ func getDeveloperListKey() []byte {
 	return developerListSubstore
 }
 
+//
+// global related
+//
+func getGlobalMetaKey() []byte {
+	return globalMetaSubStore
+}
+
+func getInflationPoolKey() []byte {
+	return inflationPoolSubStore
+}
+
 //
 // infra related
 //
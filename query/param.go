@@ -159,3 +159,17 @@ func (query *Query) GetPostParam(ctx context.Context) (*model.PostParam, error)
 	}
 	return param, nil
 }
+
+// GetPostLimits returns the limits enforced on posts by the blockchain, so that
+// posts can be validated by PostLimits.ValidatePost before building messages.
+// The lengths only change with a blockchain upgrade, so the result can be kept
+// by the caller and refreshed once in a while for PostIntervalSec.
+func (query *Query) GetPostLimits(ctx context.Context) (*model.PostLimits, error) {
+	param, err := query.GetPostParam(ctx)
+	if err != nil {
+		return nil, err
+	}
+	limits := model.DefaultPostLimits()
+	limits.PostIntervalSec = param.PostIntervalSec
+	return &limits, nil
+}
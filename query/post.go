@@ -2,63 +2,164 @@ package query
 
 import (
 	"context"
+	"sort"
+	"strings"
+	"sync"
 
+	"github.com/lino-network/lino-go/errors"
 	"github.com/lino-network/lino-go/model"
+	"github.com/lino-network/lino-go/transport"
 )
 
 // GetPostInfo returns post info given a permlink(author#postID).
+// Deleting a post clears its info, so a PostDeleted error is returned for
+// a deleted post instead of an empty post info.
 func (query *Query) GetPostInfo(ctx context.Context, author, postID string) (*model.PostInfo, error) {
 	permlink := getPermlink(author, postID)
-	resp, err := query.transport.Query(ctx, getPostInfoKey(permlink), PostKVStoreKey)
-	if err != nil {
-		return nil, err
-	}
 	postInfo := new(model.PostInfo)
-	if err := query.transport.Cdc.UnmarshalJSON(resp, postInfo); err != nil {
+	if err := query.queryAndDecode(ctx, getPostInfoKey(permlink), PostKVStoreKey, postInfo); err != nil {
 		return nil, err
 	}
+	// only a cleared post can be deleted, which saves the meta query for other posts
+	if postInfo.IsCleared() {
+		postMeta, err := query.GetPostMeta(ctx, author, postID)
+		if err != nil {
+			return nil, err
+		}
+		if postMeta.IsDeleted {
+			return nil, errors.PostDeletedf("post %v is deleted", permlink)
+		}
+	}
 	return postInfo, nil
 }
 
 // GetPostMeta returns post meta given a permlink.
 func (query *Query) GetPostMeta(ctx context.Context, author, postID string) (*model.PostMeta, error) {
 	permlink := getPermlink(author, postID)
-	resp, err := query.transport.Query(ctx, getPostMetaKey(permlink), PostKVStoreKey)
+	postMeta := new(model.PostMeta)
+	if err := query.queryAndDecode(ctx, getPostMetaKey(permlink), PostKVStoreKey, postMeta); err != nil {
+		return nil, err
+	}
+	return postMeta, nil
+}
+
+// GetPostMetas returns the post meta of each permlink(author#postID), keyed by
+// permlink. The metas are queried concurrently with the bound of
+// transport.QueryBatch, it fails if any of them can't be read.
+func (query *Query) GetPostMetas(ctx context.Context, permlinks []string) (map[string]*model.PostMeta, error) {
+	requests := make([]transport.QueryRequest, 0, len(permlinks))
+	for _, permlink := range permlinks {
+		requests = append(requests, transport.QueryRequest{
+			Key:       getPostMetaKey(permlink),
+			StoreName: PostKVStoreKey,
+		})
+	}
+	responses, err := query.transport.QueryBatch(ctx, requests)
 	if err != nil {
 		return nil, err
 	}
-	postMeta := new(model.PostMeta)
-	if err := query.transport.Cdc.UnmarshalJSON(resp, postMeta); err != nil {
+
+	permlinkToMetaMap := make(map[string]*model.PostMeta, len(permlinks))
+	for i, resp := range responses {
+		if resp.Err != nil {
+			return nil, errors.QueryFailf("GetPostMetas: failed to get meta of %v", permlinks[i]).AddCause(resp.Err)
+		}
+		postMeta := new(model.PostMeta)
+		if err := query.transport.Cdc.UnmarshalJSON(resp.Value, postMeta); err != nil {
+			return nil, errors.DecodeFailedf("GetPostMetas: failed to decode meta of %v", permlinks[i]).AddCause(err).AddRawData(resp.Value)
+		}
+		permlinkToMetaMap[permlinks[i]] = postMeta
+	}
+	return permlinkToMetaMap, nil
+}
+
+// GetPostTotalReward returns the total reward a post has received so far.
+func (query *Query) GetPostTotalReward(ctx context.Context, author, postID string) (model.Coin, error) {
+	postMeta, err := query.GetPostMeta(ctx, author, postID)
+	if err != nil {
+		return model.Coin{}, err
+	}
+	return postMeta.TotalReward, nil
+}
+
+// GetPostRewardHistory returns all reward events of a post, in reverse-chronological order.
+// Lino blockchain records neither the time nor the height of a reward event,
+// so events can only be ordered, not bucketed by time.
+func (query *Query) GetPostRewardHistory(ctx context.Context, author, postID string) (*model.RewardHistory, error) {
+	allRewardHistory, err := query.GetAllRewardHistory(ctx, author)
+	if err != nil {
 		return nil, err
 	}
-	return postMeta, nil
+
+	postRewardHistory := new(model.RewardHistory)
+	for _, detail := range allRewardHistory.Details {
+		if detail.PostAuthor == author && detail.PostID == postID {
+			postRewardHistory.Details = append(postRewardHistory.Details, detail)
+		}
+	}
+	return postRewardHistory, nil
+}
+
+// maxPostSourceChainLength is the max number of posts returned by GetPostSourceChain.
+const maxPostSourceChainLength = 100
+
+// GetPostSourceChain returns the post followed by its sources, from the post
+// itself to the original post, by following SourceAuthor and SourcePostID.
+// An InvalidArg error is returned if the chain has a cycle or is longer than
+// maxPostSourceChainLength.
+func (query *Query) GetPostSourceChain(ctx context.Context, author, postID string) ([]*model.PostInfo, error) {
+	var chain []*model.PostInfo
+	visited := make(map[string]bool)
+	for author != "" && postID != "" {
+		permlink := getPermlink(author, postID)
+		if visited[permlink] {
+			return nil, errors.InvalidArgf("GetPostSourceChain: cycle at post %v", permlink)
+		}
+		if len(chain) >= maxPostSourceChainLength {
+			return nil, errors.InvalidArgf("GetPostSourceChain: more than %v posts in chain", maxPostSourceChainLength)
+		}
+		visited[permlink] = true
+
+		postInfo, err := query.GetPostInfo(ctx, author, postID)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, postInfo)
+		author, postID = postInfo.SourceAuthor, postInfo.SourcePostID
+	}
+	return chain, nil
 }
 
 // GetPostComment returns a specific comment of a post given the post permlink
 // and comment permlink.
 func (query *Query) GetPostComment(ctx context.Context, author, postID, commentPermlink string) (*model.Comment, error) {
 	permlink := getPermlink(author, postID)
-	resp, err := query.transport.Query(ctx, getPostCommentKey(permlink, commentPermlink), PostKVStoreKey)
-
-	if err != nil {
-		return nil, err
-	}
 	comment := new(model.Comment)
-	if err := query.transport.Cdc.UnmarshalJSON(resp, comment); err != nil {
+	if err := query.queryAndDecode(ctx, getPostCommentKey(permlink, commentPermlink), PostKVStoreKey, comment); err != nil {
 		return nil, err
 	}
 	return comment, nil
 }
 
-// GetPostView returns a view of a post performed by a user.
-func (query *Query) GetPostView(ctx context.Context, author, postID, viewUser string) (*model.View, error) {
+// DoesPostCommentExist returns true if a comment with commentPermlink
+// exists under the post given by author and postID.
+func (query *Query) DoesPostCommentExist(ctx context.Context, author, postID, commentPermlink string) (bool, error) {
 	permlink := getPermlink(author, postID)
-	resp, err := query.transport.Query(ctx, getPostViewKey(permlink, viewUser), PostKVStoreKey)
+	_, err := query.transport.Query(ctx, getPostCommentKey(permlink, commentPermlink), PostKVStoreKey)
 	if err != nil {
-		return nil, err
+		if isEmptyResponse(err) {
+			return false, nil
+		}
+		return false, err
 	}
+	return true, nil
+}
+
+// GetPostView returns a view of a post performed by a user.
+func (query *Query) GetPostView(ctx context.Context, author, postID, viewUser string) (*model.View, error) {
+	permlink := getPermlink(author, postID)
 	view := new(model.View)
-	if err := query.transport.Cdc.UnmarshalJSON(resp, view); err != nil {
+	if err := query.queryAndDecode(ctx, getPostViewKey(permlink, viewUser), PostKVStoreKey, view); err != nil {
 		return nil, err
 	}
 	return view, nil
@@ -67,12 +168,8 @@ func (query *Query) GetPostView(ctx context.Context, author, postID, viewUser st
 // GetPostDonations returns all donations that a user has given to a post.
 func (query *Query) GetPostDonations(ctx context.Context, author, postID, donateUser string) (*model.Donations, error) {
 	permlink := getPermlink(author, postID)
-	resp, err := query.transport.Query(ctx, getPostDonationsKey(permlink, donateUser), PostKVStoreKey)
-	if err != nil {
-		return nil, err
-	}
 	donations := new(model.Donations)
-	if err := query.transport.Cdc.UnmarshalJSON(resp, donations); err != nil {
+	if err := query.queryAndDecode(ctx, getPostDonationsKey(permlink, donateUser), PostKVStoreKey, donations); err != nil {
 		return nil, err
 	}
 	return donations, nil
@@ -81,12 +178,8 @@ func (query *Query) GetPostDonations(ctx context.Context, author, postID, donate
 // GetPostReportOrUpvote returns report or upvote that a user has given to a post.
 func (query *Query) GetPostReportOrUpvote(ctx context.Context, author, postID, user string) (*model.ReportOrUpvote, error) {
 	permlink := getPermlink(author, postID)
-	resp, err := query.transport.Query(ctx, getPostReportOrUpvoteKey(permlink, user), PostKVStoreKey)
-	if err != nil {
-		return nil, err
-	}
 	reportOrUpvote := new(model.ReportOrUpvote)
-	if err := query.transport.Cdc.UnmarshalJSON(resp, reportOrUpvote); err != nil {
+	if err := query.queryAndDecode(ctx, getPostReportOrUpvoteKey(permlink, user), PostKVStoreKey, reportOrUpvote); err != nil {
 		return nil, err
 	}
 	return reportOrUpvote, nil
@@ -97,50 +190,207 @@ func (query *Query) GetPostReportOrUpvote(ctx context.Context, author, postID, u
 //
 
 // GetUserAllPosts returns all posts that a user has created.
+// It fails if the meta of any post can't be read, see GetUserAllPostsWithErrors
+// for a partial result.
 func (query *Query) GetUserAllPosts(ctx context.Context, username string) (map[string]*model.Post, error) {
-	resKVs, err := query.transport.QuerySubspace(ctx, append(getUserPostInfoPrefix(username), PermLinkSeparator...), PostKVStoreKey)
+	permlinkToPostMap, permlinkToErrMap, err := query.GetUserAllPostsWithErrors(ctx, username)
 	if err != nil {
 		return nil, err
 	}
 
+	if len(permlinkToErrMap) > 0 {
+		permlinks := make([]string, 0, len(permlinkToErrMap))
+		for permlink := range permlinkToErrMap {
+			permlinks = append(permlinks, permlink)
+		}
+		sort.Strings(permlinks)
+		return nil, permlinkToErrMap[permlinks[0]]
+	}
+	return permlinkToPostMap, nil
+}
+
+// GetUserAllPostsWithErrors returns all posts that a user has created, the meta
+// of the posts are queried concurrently. A post whose meta can't be read is left
+// out of the returned posts and its error is returned in the second map under the
+// same permlink key, so that callers can still render the other posts.
+// The returned error is only non-nil if the list of posts itself can't be read.
+func (query *Query) GetUserAllPostsWithErrors(
+	ctx context.Context, username string) (map[string]*model.Post, map[string]error, error) {
+	return query.getUserPosts(ctx, username, model.PostFilter{})
+}
+
+// GetUserFilteredPosts returns the posts that a user has created and match filter,
+// e.g. only top-level posts which are not deleted. Errors are reported per permlink
+// as in GetUserAllPostsWithErrors.
+func (query *Query) GetUserFilteredPosts(ctx context.Context, username string,
+	filter model.PostFilter) (map[string]*model.Post, map[string]error, error) {
+	return query.getUserPosts(ctx, username, filter)
+}
+
+// SearchUserPosts returns the posts that a user has created whose title or content
+// contains substr, ignoring case. The posts are all read and matched on the client,
+// there is no index on blockchain. Errors are reported per permlink as in
+// GetUserAllPostsWithErrors.
+func (query *Query) SearchUserPosts(ctx context.Context, username, substr string) (
+	map[string]*model.Post, map[string]error, error) {
+	return query.getUserPosts(ctx, username, model.PostFilter{Contains: substr})
+}
+
+// GetUserPostsSinceHeight returns the posts that a user has created since the block
+// at height, e.g. to refresh a feed. A post only records the time of its block, so
+// posts of earlier blocks in the same second are included too. All posts are read
+// and filtered on the client. Errors are reported per permlink as in
+// GetUserAllPostsWithErrors.
+func (query *Query) GetUserPostsSinceHeight(ctx context.Context, username string, height int64) (
+	map[string]*model.Post, map[string]error, error) {
+	block, err := query.transport.QueryBlock(ctx, height)
+	if err != nil {
+		return nil, nil, errors.QueryFailf("GetUserPostsSinceHeight: failed to get block %v", height).AddCause(err)
+	}
+	return query.getUserPosts(ctx, username, model.PostFilter{CreatedSince: block.BlockMeta.Header.Time.Unix()})
+}
+
+func (query *Query) getUserPosts(ctx context.Context, username string,
+	filter model.PostFilter) (map[string]*model.Post, map[string]error, error) {
+	resKVs, err := query.transport.QuerySubspace(ctx, append(getUserPostInfoPrefix(username), PermLinkSeparator...), PostKVStoreKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	permlinkToPostMap := make(map[string]*model.Post)
+	permlinkToErrMap := make(map[string]error)
+	var permlinks []string
+	var postInfos []*model.PostInfo
+	var requests []transport.QueryRequest
 	for _, KV := range resKVs {
+		permlink := getSubstringAfterSubstore(KV.Key)
 		postInfo := new(model.PostInfo)
 		if err := query.transport.Cdc.UnmarshalJSON(KV.Value, postInfo); err != nil {
-			return nil, err
+			permlinkToErrMap[permlink] = err
+			continue
+		}
+		// skip the posts known from the info not to match before querying the meta
+		if !filter.MatchInfo(postInfo) {
+			continue
 		}
+		permlinks = append(permlinks, permlink)
+		postInfos = append(postInfos, postInfo)
+		requests = append(requests, transport.QueryRequest{
+			Key:       getPostMetaKey(getPermlink(postInfo.Author, postInfo.PostID)),
+			StoreName: PostKVStoreKey,
+		})
+	}
 
-		pm, err := query.GetPostMeta(ctx, postInfo.Author, postInfo.PostID)
-		if err != nil {
-			return nil, err
+	// a query cut off by ctx is reported per permlink, so the error of the batch is not needed
+	responses, _ := query.transport.QueryBatch(ctx, requests)
+	for i, resp := range responses {
+		if resp.Err != nil {
+			permlinkToErrMap[permlinks[i]] = resp.Err
+			continue
+		}
+		pm := new(model.PostMeta)
+		if err := query.transport.Cdc.UnmarshalJSON(resp.Value, pm); err != nil {
+			permlinkToErrMap[permlinks[i]] = errors.DecodeFailedf("failed to decode meta of %v", permlinks[i]).AddCause(err).AddRawData(resp.Value)
+			continue
 		}
+		if post := newPost(postInfos[i], pm); filter.Match(post) {
+			permlinkToPostMap[permlinks[i]] = post
+		}
+	}
 
-		post := &model.Post{
-			PostID:                  postInfo.PostID,
-			Title:                   postInfo.Title,
-			Content:                 postInfo.Content,
-			Author:                  postInfo.Author,
-			ParentAuthor:            postInfo.ParentAuthor,
-			ParentPostID:            postInfo.ParentPostID,
-			SourceAuthor:            postInfo.SourceAuthor,
-			SourcePostID:            postInfo.SourcePostID,
-			Links:                   postInfo.Links,
-			CreatedAt:               pm.CreatedAt,
-			LastUpdatedAt:           pm.LastUpdatedAt,
-			LastActivityAt:          pm.LastActivityAt,
-			AllowReplies:            pm.AllowReplies,
-			IsDeleted:               pm.IsDeleted,
-			TotalDonateCount:        pm.TotalDonateCount,
-			TotalReportCoinDay:      pm.TotalReportCoinDay,
-			TotalUpvoteCoinDay:      pm.TotalUpvoteCoinDay,
-			TotalViewCount:          pm.TotalViewCount,
-			TotalReward:             pm.TotalReward,
-			RedistributionSplitRate: pm.RedistributionSplitRate,
-		}
-		permlinkToPostMap[getSubstringAfterSubstore(KV.Key)] = post
+	return permlinkToPostMap, permlinkToErrMap, nil
+}
+
+// newPost merges the info and the meta of a post.
+func newPost(postInfo *model.PostInfo, pm *model.PostMeta) *model.Post {
+	return &model.Post{
+		PostID:                  postInfo.PostID,
+		Title:                   postInfo.Title,
+		Content:                 postInfo.Content,
+		Author:                  postInfo.Author,
+		ParentAuthor:            postInfo.ParentAuthor,
+		ParentPostID:            postInfo.ParentPostID,
+		SourceAuthor:            postInfo.SourceAuthor,
+		SourcePostID:            postInfo.SourcePostID,
+		Links:                   postInfo.Links,
+		CreatedAt:               pm.CreatedAt,
+		LastUpdatedAt:           pm.LastUpdatedAt,
+		LastActivityAt:          pm.LastActivityAt,
+		AllowReplies:            pm.AllowReplies,
+		IsDeleted:               pm.IsDeleted,
+		TotalDonateCount:        pm.TotalDonateCount,
+		TotalReportCoinDay:      pm.TotalReportCoinDay,
+		TotalUpvoteCoinDay:      pm.TotalUpvoteCoinDay,
+		TotalViewCount:          pm.TotalViewCount,
+		TotalReward:             pm.TotalReward,
+		RedistributionSplitRate: pm.RedistributionSplitRate,
 	}
+}
 
-	return permlinkToPostMap, nil
+// Sections of PostEngagement, the keys of the errors returned by GetPostEngagement.
+const (
+	EngagementViews           = "views"
+	EngagementComments        = "comments"
+	EngagementDonations       = "donations"
+	EngagementReportOrUpvotes = "report_or_upvotes"
+)
+
+// GetPostEngagement returns the views, comments, donations and reports or upvotes
+// of a post, the four sections are queried concurrently. A section which can't be
+// read is left nil and its error is returned in the map under its Engagement key.
+// The returned error is only non-nil if a section is cut off by ctx.
+func (query *Query) GetPostEngagement(ctx context.Context, author, postID string) (
+	*model.PostEngagement, map[string]error, error) {
+	engagement := &model.PostEngagement{}
+	var viewsErr, commentsErr, donationsErr, reportOrUpvotesErr error
+
+	var wg sync.WaitGroup
+	wg.Add(4)
+	go func() {
+		defer wg.Done()
+		engagement.Views, viewsErr = query.GetPostAllViews(ctx, author, postID)
+	}()
+	go func() {
+		defer wg.Done()
+		engagement.Comments, commentsErr = query.GetPostAllComments(ctx, author, postID)
+	}()
+	go func() {
+		defer wg.Done()
+		engagement.Donations, donationsErr = query.GetPostAllDonations(ctx, author, postID)
+	}()
+	go func() {
+		defer wg.Done()
+		engagement.ReportOrUpvotes, reportOrUpvotesErr = query.GetPostAllReportOrUpvotes(ctx, author, postID)
+	}()
+	wg.Wait()
+
+	sectionToErrMap, err := engagementErrors(ctx, map[string]error{
+		EngagementViews:           viewsErr,
+		EngagementComments:        commentsErr,
+		EngagementDonations:       donationsErr,
+		EngagementReportOrUpvotes: reportOrUpvotesErr,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return engagement, sectionToErrMap, nil
+}
+
+// engagementErrors drops the sections read without error. ctx may be done
+// after all sections are read, so it only fails the call if a section was
+// actually cut off by ctx.
+func engagementErrors(ctx context.Context, sectionErrs map[string]error) (map[string]error, error) {
+	sectionToErrMap := make(map[string]error)
+	for section, err := range sectionErrs {
+		if err == nil {
+			continue
+		}
+		if ctx.Err() != nil && isTimeout(err) {
+			return nil, errors.Timeout("GetPostEngagement timeout").AddCause(ctx.Err())
+		}
+		sectionToErrMap[section] = err
+	}
+	return sectionToErrMap, nil
 }
 
 // GetPostAllComments returns all comments that a post has.
@@ -164,6 +414,111 @@ func (query *Query) GetPostAllComments(ctx context.Context, author, postID strin
 	return permlinkToCommentsMap, nil
 }
 
+// GetPostCommentTree returns the comments of a post together with their replies,
+// recursively up to maxDepth levels, where 1 means direct comments only.
+// At most maxNodes comments are fetched, if there are more the tree is marked as truncated.
+// Comments of the same level are sorted by creation time.
+func (query *Query) GetPostCommentTree(ctx context.Context, author, postID string, maxDepth, maxNodes int) (*model.CommentTree, error) {
+	if maxDepth <= 0 || maxNodes <= 0 {
+		return nil, errors.InvalidArgf("GetPostCommentTree: maxDepth [%v] or maxNodes [%v] is invalid", maxDepth, maxNodes)
+	}
+
+	tree := &model.CommentTree{}
+	type parent struct {
+		author  string
+		postID  string
+		replies *[]*model.CommentNode
+		depth   int
+	}
+	queue := []parent{{author: author, postID: postID, replies: &tree.Comments, depth: 1}}
+	numOfNodes := 0
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+
+		comments, err := query.GetPostAllComments(ctx, p.author, p.postID)
+		if err != nil {
+			return nil, err
+		}
+
+		var sorted []*model.Comment
+		for _, comment := range comments {
+			sorted = append(sorted, comment)
+		}
+		sort.Slice(sorted, func(i, j int) bool {
+			if sorted[i].CreatedAt != sorted[j].CreatedAt {
+				return sorted[i].CreatedAt < sorted[j].CreatedAt
+			}
+			return getPermlink(sorted[i].Author, sorted[i].PostID) < getPermlink(sorted[j].Author, sorted[j].PostID)
+		})
+
+		for _, comment := range sorted {
+			if numOfNodes == maxNodes {
+				tree.Truncated = true
+				return tree, nil
+			}
+			numOfNodes++
+
+			node := &model.CommentNode{Comment: comment}
+			*p.replies = append(*p.replies, node)
+			if p.depth < maxDepth {
+				queue = append(queue, parent{author: comment.Author, postID: comment.PostID, replies: &node.Replies, depth: p.depth + 1})
+			}
+		}
+	}
+
+	return tree, nil
+}
+
+// GetUserAllDonations returns all donations a donor has given, keyed by post permlink,
+// together with the totals. The chain doesn't index donations by donor, so they are
+// reconstructed from the donor's donation relationships: every post of every author
+// the donor has donated to is checked, which costs one query per post.
+func (query *Query) GetUserAllDonations(ctx context.Context, donor string) (*model.UserDonations, error) {
+	relationships, err := query.GetAllRelationships(ctx, donor)
+	if err != nil {
+		return nil, err
+	}
+
+	userDonations := &model.UserDonations{
+		Donor:       donor,
+		TotalAmount: model.NewCoinFromInt64(0),
+		Posts:       make(map[string]*model.Donations),
+	}
+	for other, relationship := range relationships {
+		if relationship.DonationTimes == 0 {
+			continue
+		}
+
+		author := strings.TrimPrefix(other, KeySeparator)
+		resKVs, err := query.transport.QuerySubspace(ctx, append(getUserPostInfoPrefix(author), PermLinkSeparator...), PostKVStoreKey)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, KV := range resKVs {
+			postInfo := new(model.PostInfo)
+			if err := query.transport.Cdc.UnmarshalJSON(KV.Value, postInfo); err != nil {
+				return nil, err
+			}
+
+			donations, err := query.GetPostDonations(ctx, postInfo.Author, postInfo.PostID, donor)
+			if err != nil {
+				if isEmptyResponse(err) {
+					continue
+				}
+				return nil, err
+			}
+
+			userDonations.Posts[getPermlink(postInfo.Author, postInfo.PostID)] = donations
+			userDonations.TotalTimes += donations.Times
+			userDonations.TotalAmount = userDonations.TotalAmount.Plus(donations.Amount)
+		}
+	}
+
+	return userDonations, nil
+}
+
 // GetPostAllViews returns all views that a post has.
 func (query *Query) GetPostAllViews(ctx context.Context, author, postID string) (map[string]*model.View, error) {
 	permlink := getPermlink(author, postID)
@@ -0,0 +1,84 @@
+package query
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lino-network/lino-go/errors"
+)
+
+func TestEngagementErrors(t *testing.T) {
+	cancelledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	testCases := map[string]struct {
+		ctx             context.Context
+		sectionErrs     map[string]error
+		expectErrCode   errors.CodeType
+		expectErrKeys   []string
+		expectCallError bool
+	}{
+		"all sections read": {
+			ctx: context.Background(),
+			sectionErrs: map[string]error{
+				EngagementViews:    nil,
+				EngagementComments: nil,
+			},
+		},
+		"ctx cancelled after all sections are read": {
+			ctx: cancelledCtx,
+			sectionErrs: map[string]error{
+				EngagementViews:    nil,
+				EngagementComments: nil,
+			},
+		},
+		"section failed without ctx": {
+			ctx: cancelledCtx,
+			sectionErrs: map[string]error{
+				EngagementViews:    errors.QueryFail("views"),
+				EngagementComments: nil,
+			},
+			expectErrKeys: []string{EngagementViews},
+		},
+		"section cut off by ctx": {
+			ctx: cancelledCtx,
+			sectionErrs: map[string]error{
+				EngagementViews:    errors.Timeout("views"),
+				EngagementComments: nil,
+			},
+			expectCallError: true,
+			expectErrCode:   errors.CodeTimeout,
+		},
+		"timeout of the node with live ctx": {
+			ctx: context.Background(),
+			sectionErrs: map[string]error{
+				EngagementViews: errors.Timeout("views"),
+			},
+			expectErrKeys: []string{EngagementViews},
+		},
+	}
+
+	for testName, tc := range testCases {
+		sectionToErrMap, err := engagementErrors(tc.ctx, tc.sectionErrs)
+		if tc.expectCallError {
+			vErr, ok := err.(errors.Error)
+			if !ok || vErr.CodeType() != tc.expectErrCode {
+				t.Errorf("%s: diff err, got %v, want code %v", testName, err, tc.expectErrCode)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected err %v", testName, err)
+			continue
+		}
+		if len(sectionToErrMap) != len(tc.expectErrKeys) {
+			t.Errorf("%s: diff errs, got %v, want keys %v", testName, sectionToErrMap, tc.expectErrKeys)
+			continue
+		}
+		for _, key := range tc.expectErrKeys {
+			if sectionToErrMap[key] == nil {
+				t.Errorf("%s: missing err of %v", testName, key)
+			}
+		}
+	}
+}
@@ -2,10 +2,177 @@ package query
 
 import (
 	"context"
+	"sort"
+	"strconv"
 
+	"github.com/lino-network/lino-go/errors"
 	"github.com/lino-network/lino-go/model"
 )
 
+// GetProposal returns a proposal, either ongoing or expired.
+func (query *Query) GetProposal(ctx context.Context, proposalID string) (model.Proposal, error) {
+	proposal, err := query.GetOngoingProposal(ctx, proposalID)
+	if err == nil {
+		return *proposal, nil
+	}
+	if !isEmptyResponse(err) {
+		return nil, err
+	}
+
+	proposal, err = query.GetExpiredProposal(ctx, proposalID)
+	if err != nil {
+		return nil, err
+	}
+	return *proposal, nil
+}
+
+// GetProposalDeadline returns when the voting of a proposal ends, which is its
+// creation time plus the decide period of its kind in ProposalParam, and the
+// time left at the latest block. The decide period is read from the current
+// ProposalParam, the expiry recorded by the proposal takes precedence if set.
+func (query *Query) GetProposalDeadline(ctx context.Context, proposalID string) (*model.ProposalDeadline, error) {
+	proposal, err := query.GetProposal(ctx, proposalID)
+	if err != nil {
+		return nil, err
+	}
+	info := proposal.GetProposalInfo()
+
+	expiredAt := info.ExpiredAt
+	if expiredAt == 0 {
+		param, err := query.GetProposalParam(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		switch proposal.(type) {
+		case *model.ChangeParamProposal:
+			expiredAt = info.CreatedAt + param.ChangeParamDecideSec
+		case *model.ContentCensorshipProposal:
+			expiredAt = info.CreatedAt + param.ContentCensorshipDecideSec
+		case *model.ProtocolUpgradeProposal:
+			expiredAt = info.CreatedAt + param.ProtocolUpgradeDecideSec
+		default:
+			return nil, errors.QueryFailf("GetProposalDeadline: unknown proposal type %T", proposal)
+		}
+	}
+
+	status, err := query.transport.QueryBlockStatus(ctx)
+	if err != nil {
+		return nil, errors.QueryFailf("GetProposalDeadline: failed to get block status").AddCause(err)
+	}
+
+	deadline := &model.ProposalDeadline{
+		ProposalID: proposalID,
+		ExpiredAt:  expiredAt,
+	}
+	if remaining := expiredAt - status.SyncInfo.LatestBlockTime.Unix(); remaining > 0 {
+		deadline.RemainingSec = remaining
+	}
+	return deadline, nil
+}
+
+// GetProposalResult returns the votes of a proposal and, once the voting has ended,
+// whether it passed and was applied. An ongoing proposal is returned as not Decided
+// with the votes so far.
+func (query *Query) GetProposalResult(ctx context.Context, proposalID string) (*model.ProposalOutcome, error) {
+	proposal, err := query.GetOngoingProposal(ctx, proposalID)
+	decided := false
+	if err != nil {
+		if !isEmptyResponse(err) {
+			return nil, err
+		}
+		if proposal, err = query.GetExpiredProposal(ctx, proposalID); err != nil {
+			return nil, err
+		}
+		decided = true
+	}
+
+	info := (*proposal).GetProposalInfo()
+	outcome := &model.ProposalOutcome{
+		ProposalID:    proposalID,
+		Decided:       decided,
+		AgreeVotes:    info.AgreeVotes,
+		DisagreeVotes: info.DisagreeVotes,
+	}
+	if decided {
+		outcome.Result = model.ProposalResult(info.Result)
+		if _, isUpgrade := (*proposal).(*model.ProtocolUpgradeProposal); !isUpgrade {
+			outcome.Applied = outcome.Result == model.ProposalPass
+		}
+	}
+	return outcome, nil
+}
+
+// GetPostContentDeletions returns the passed content censorship proposals of a post,
+// i.e. the deletions of its content by DeletePostContent and their reasons.
+// The expired proposals are all read, so it is slow when there are many of them.
+func (query *Query) GetPostContentDeletions(ctx context.Context, author, postID string) ([]*model.ContentDeletion, error) {
+	proposals, err := query.GetExpiredProposalList(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return contentDeletionsOf(proposals, getPermlink(author, postID)), nil
+}
+
+// contentDeletionsOf returns the passed content censorship proposals of permlink among proposals.
+func contentDeletionsOf(proposals []*model.Proposal, permlink string) []*model.ContentDeletion {
+	var deletions []*model.ContentDeletion
+	for _, proposal := range proposals {
+		censorship, ok := (*proposal).(*model.ContentCensorshipProposal)
+		if !ok || censorship.PermLink != permlink ||
+			model.ProposalResult(censorship.ProposalInfo.Result) != model.ProposalPass {
+			continue
+		}
+		deletions = append(deletions, &model.ContentDeletion{
+			ProposalID: censorship.ProposalID,
+			Creator:    censorship.Creator,
+			Permlink:   censorship.PermLink,
+			Reason:     censorship.Reason,
+			DeletedAt:  censorship.ExpiredAt,
+		})
+	}
+	return deletions
+}
+
+// GetProposalMinDeposit returns the deposit the blockchain takes from the creator
+// of a proposal of proposalType, as set in ProposalParam.
+func (query *Query) GetProposalMinDeposit(ctx context.Context, proposalType model.ProposalType) (model.Coin, error) {
+	param, err := query.GetProposalParam(ctx)
+	if err != nil {
+		return model.Coin{}, err
+	}
+
+	switch proposalType {
+	case model.ChangeParamProposalType:
+		return param.ChangeParamMinDeposit, nil
+	case model.ContentCensorshipProposalType:
+		return param.ContentCensorshipMinDeposit, nil
+	case model.ProtocolUpgradeProposalType:
+		return param.ProtocolUpgradeMinDeposit, nil
+	default:
+		return model.Coin{}, errors.InvalidArgf("GetProposalMinDeposit: unknown proposal type %v", proposalType)
+	}
+}
+
+// CheckProposalDeposit returns an InsufficientBalance error if the saving of
+// creator is below the deposit of a proposal of proposalType, in which case the
+// proposal would be rejected by the blockchain.
+func (query *Query) CheckProposalDeposit(ctx context.Context, creator string, proposalType model.ProposalType) error {
+	deposit, err := query.GetProposalMinDeposit(ctx, proposalType)
+	if err != nil {
+		return err
+	}
+	bank, err := query.GetAccountBank(ctx, creator)
+	if err != nil {
+		return err
+	}
+	if !bank.Saving.IsGTE(deposit) {
+		return errors.InsufficientBalancef("saving %v of %v is less than proposal deposit %v",
+			bank.Saving.Amount.String(), creator, deposit.Amount.String())
+	}
+	return nil
+}
+
 // GetOngoingProposal returns one ongoing proposal.
 func (query *Query) GetOngoingProposal(ctx context.Context, proposalID string) (*model.Proposal, error) {
 	resp, err := query.transport.Query(ctx, getOngoingProposalKey(proposalID), ProposalKVStoreKey)
@@ -14,7 +181,7 @@ func (query *Query) GetOngoingProposal(ctx context.Context, proposalID string) (
 	}
 
 	proposal := new(model.Proposal)
-	if err := query.transport.Cdc.UnmarshalJSON(resp, proposal); err != nil {
+	if err := query.transport.ProposalCdc.UnmarshalJSON(resp, proposal); err != nil {
 		return nil, err
 	}
 	return proposal, nil
@@ -30,7 +197,7 @@ func (query *Query) GetOngoingProposalList(ctx context.Context) ([]*model.Propos
 	var proposals []*model.Proposal
 	for _, KV := range resKVs {
 		proposal := new(model.Proposal)
-		if err := query.transport.Cdc.UnmarshalJSON(KV.Value, proposal); err != nil {
+		if err := query.transport.ProposalCdc.UnmarshalJSON(KV.Value, proposal); err != nil {
 			return nil, err
 		}
 		proposals = append(proposals, proposal)
@@ -47,7 +214,7 @@ func (query *Query) GetExpiredProposal(ctx context.Context, proposalID string) (
 	}
 
 	proposal := new(model.Proposal)
-	if err := query.transport.Cdc.UnmarshalJSON(resp, proposal); err != nil {
+	if err := query.transport.ProposalCdc.UnmarshalJSON(resp, proposal); err != nil {
 		return nil, err
 	}
 	return proposal, nil
@@ -63,7 +230,7 @@ func (query *Query) GetExpiredProposalList(ctx context.Context) ([]*model.Propos
 	var proposals []*model.Proposal
 	for _, KV := range resKVs {
 		proposal := new(model.Proposal)
-		if err := query.transport.Cdc.UnmarshalJSON(KV.Value, proposal); err != nil {
+		if err := query.transport.ProposalCdc.UnmarshalJSON(KV.Value, proposal); err != nil {
 			return nil, err
 		}
 		proposals = append(proposals, proposal)
@@ -72,6 +239,82 @@ func (query *Query) GetExpiredProposalList(ctx context.Context) ([]*model.Propos
 	return proposals, nil
 }
 
+// GetOngoingProposalPage returns a page of the ongoing proposals, see model.ProposalListOptions.
+// The proposals are all read and paged on the client.
+func (query *Query) GetOngoingProposalPage(ctx context.Context, opts model.ProposalListOptions) (*model.ProposalPage, error) {
+	proposals, err := query.GetOngoingProposalList(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return pageProposals(proposals, opts)
+}
+
+// GetExpiredProposalPage returns a page of the expired proposals, see model.ProposalListOptions.
+// The proposals are all read and paged on the client.
+func (query *Query) GetExpiredProposalPage(ctx context.Context, opts model.ProposalListOptions) (*model.ProposalPage, error) {
+	proposals, err := query.GetExpiredProposalList(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return pageProposals(proposals, opts)
+}
+
+// pageProposals sorts proposals by ID and returns the page selected by opts.
+// The cursor is the ID of the last proposal of the previous page.
+func pageProposals(proposals []*model.Proposal, opts model.ProposalListOptions) (*model.ProposalPage, error) {
+	var cursorID int64
+	if opts.Cursor != "" {
+		id, err := strconv.ParseInt(opts.Cursor, 10, 64)
+		if err != nil {
+			return nil, errors.InvalidArgf("invalid proposal cursor %v", opts.Cursor).AddCause(err)
+		}
+		cursorID = id
+	}
+
+	type idProposal struct {
+		id       int64
+		proposal model.Proposal
+	}
+	var selected []idProposal
+	for _, proposal := range proposals {
+		if len(opts.Types) > 0 {
+			proposalType, ok := model.GetProposalType(*proposal)
+			if !ok || !containsProposalType(opts.Types, proposalType) {
+				continue
+			}
+		}
+		proposalID := (*proposal).GetProposalInfo().ProposalID
+		id, err := strconv.ParseInt(proposalID, 10, 64)
+		if err != nil {
+			return nil, errors.DecodeFailedf("invalid proposal ID %v", proposalID).AddCause(err)
+		}
+		if opts.Cursor != "" && id <= cursorID {
+			continue
+		}
+		selected = append(selected, idProposal{id: id, proposal: *proposal})
+	}
+	sort.Slice(selected, func(i, j int) bool { return selected[i].id < selected[j].id })
+
+	page := &model.ProposalPage{Proposals: []model.Proposal{}}
+	if opts.Limit > 0 && len(selected) > opts.Limit {
+		selected = selected[:opts.Limit]
+		page.NextCursor = strconv.FormatInt(selected[len(selected)-1].id, 10)
+	}
+	for _, p := range selected {
+		page.Proposals = append(page.Proposals, p.proposal)
+	}
+	return page, nil
+}
+
+func containsProposalType(types []model.ProposalType, target model.ProposalType) bool {
+	for _, t := range types {
+		if t == target {
+			return true
+		}
+	}
+	return false
+}
+
 // GetProposal returns proposal info of a specific proposalID.
 func (query *Query) GetNextProposalID(ctx context.Context) (*model.NextProposalID, error) {
 	resp, err := query.transport.Query(ctx, getNextProposalIDKey(), ProposalKVStoreKey)
@@ -80,7 +323,7 @@ func (query *Query) GetNextProposalID(ctx context.Context) (*model.NextProposalI
 	}
 
 	nextProposalID := new(model.NextProposalID)
-	if err := query.transport.Cdc.UnmarshalJSON(resp, nextProposalID); err != nil {
+	if err := query.transport.ProposalCdc.UnmarshalJSON(resp, nextProposalID); err != nil {
 		return nil, err
 	}
 	return nextProposalID, nil
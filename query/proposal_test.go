@@ -0,0 +1,141 @@
+package query
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lino-network/lino-go/model"
+)
+
+func TestContentDeletionsOf(t *testing.T) {
+	newCensorship := func(proposalID, permlink string, result model.ProposalResult) *model.Proposal {
+		var proposal model.Proposal = &model.ContentCensorshipProposal{
+			ProposalInfo: model.ProposalInfo{
+				Creator:    "creator",
+				ProposalID: proposalID,
+				Result:     int(result),
+				ExpiredAt:  100,
+			},
+			PermLink: permlink,
+			Reason:   "reason " + proposalID,
+		}
+		return &proposal
+	}
+	var upgrade model.Proposal = &model.ProtocolUpgradeProposal{
+		ProposalInfo: model.ProposalInfo{ProposalID: "4", Result: int(model.ProposalPass)},
+	}
+	proposals := []*model.Proposal{
+		newCensorship("1", "author#post", model.ProposalPass),
+		newCensorship("2", "author#post", model.ProposalNotPass),
+		newCensorship("3", "author#other", model.ProposalPass),
+		&upgrade,
+	}
+
+	testCases := map[string]struct {
+		permlink          string
+		expectProposalIDs []string
+	}{
+		"passed censorship of the post": {
+			permlink:          "author#post",
+			expectProposalIDs: []string{"1"},
+		},
+		"no censorship": {
+			permlink:          "author#none",
+			expectProposalIDs: nil,
+		},
+	}
+
+	for testName, tc := range testCases {
+		deletions := contentDeletionsOf(proposals, tc.permlink)
+		if len(deletions) != len(tc.expectProposalIDs) {
+			t.Errorf("%s: diff number of deletions, got %v, want %v", testName, len(deletions), len(tc.expectProposalIDs))
+			continue
+		}
+		for i, deletion := range deletions {
+			if deletion.ProposalID != tc.expectProposalIDs[i] {
+				t.Errorf("%s: diff proposal, got %v, want %v", testName, deletion.ProposalID, tc.expectProposalIDs[i])
+			}
+			if deletion.Reason != "reason "+deletion.ProposalID || deletion.Permlink != tc.permlink || deletion.DeletedAt != 100 {
+				t.Errorf("%s: diff deletion, got %+v", testName, deletion)
+			}
+		}
+	}
+}
+
+func TestPageProposals(t *testing.T) {
+	newProposal := func(proposal model.Proposal) *model.Proposal {
+		return &proposal
+	}
+	proposals := []*model.Proposal{
+		newProposal(&model.ChangeParamProposal{ProposalInfo: model.ProposalInfo{ProposalID: "10"}}),
+		newProposal(&model.ContentCensorshipProposal{ProposalInfo: model.ProposalInfo{ProposalID: "2"}}),
+		newProposal(&model.ProtocolUpgradeProposal{ProposalInfo: model.ProposalInfo{ProposalID: "9"}}),
+		newProposal(&model.ChangeParamProposal{ProposalInfo: model.ProposalInfo{ProposalID: "1"}}),
+	}
+
+	testCases := map[string]struct {
+		opts         model.ProposalListOptions
+		expectIDs    []string
+		expectCursor string
+		expectErr    bool
+	}{
+		"all in order of ID": {
+			opts:      model.ProposalListOptions{},
+			expectIDs: []string{"1", "2", "9", "10"},
+		},
+		"first page": {
+			opts:         model.ProposalListOptions{Limit: 2},
+			expectIDs:    []string{"1", "2"},
+			expectCursor: "2",
+		},
+		"last page": {
+			opts:      model.ProposalListOptions{Cursor: "2", Limit: 2},
+			expectIDs: []string{"9", "10"},
+		},
+		"filter by type": {
+			opts:      model.ProposalListOptions{Types: []model.ProposalType{model.ChangeParamProposalType}},
+			expectIDs: []string{"1", "10"},
+		},
+		"filter by type with cursor": {
+			opts: model.ProposalListOptions{
+				Types:  []model.ProposalType{model.ChangeParamProposalType, model.ProtocolUpgradeProposalType},
+				Cursor: "1",
+				Limit:  1,
+			},
+			expectIDs:    []string{"9"},
+			expectCursor: "9",
+		},
+		"after the last proposal": {
+			opts:      model.ProposalListOptions{Cursor: "10"},
+			expectIDs: []string{},
+		},
+		"invalid cursor": {
+			opts:      model.ProposalListOptions{Cursor: "abc"},
+			expectErr: true,
+		},
+	}
+
+	for testName, tc := range testCases {
+		page, err := pageProposals(proposals, tc.opts)
+		if tc.expectErr {
+			if err == nil {
+				t.Errorf("%s: expect error", testName)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: got err %v", testName, err)
+			continue
+		}
+		ids := []string{}
+		for _, proposal := range page.Proposals {
+			ids = append(ids, proposal.GetProposalInfo().ProposalID)
+		}
+		if !reflect.DeepEqual(ids, tc.expectIDs) {
+			t.Errorf("%s: diff proposals, got %v, want %v", testName, ids, tc.expectIDs)
+		}
+		if page.NextCursor != tc.expectCursor {
+			t.Errorf("%s: diff cursor, got %v, want %v", testName, page.NextCursor, tc.expectCursor)
+		}
+	}
+}
@@ -4,12 +4,18 @@ package query
 
 import (
 	"context"
+	"encoding/hex"
+	"strings"
 
 	"github.com/lino-network/lino-go/errors"
 	"github.com/lino-network/lino-go/model"
 	"github.com/lino-network/lino-go/transport"
 )
 
+// maxUnconfirmedTxs is the max number of unconfirmed transactions
+// returned by the node in one query.
+const maxUnconfirmedTxs = 100
+
 // Query is a wrapper of querying data from blockchain.
 type Query struct {
 	transport *transport.Transport
@@ -45,6 +51,36 @@ func (query *Query) GetBlock(ctx context.Context, height int64) (*model.Block, e
 	return block, nil
 }
 
+// GetBlockResults returns the results of the transactions and
+// the events of begin and end block at a certain height.
+func (query *Query) GetBlockResults(ctx context.Context, height int64) (*model.BlockResults, error) {
+	resp, err := query.transport.QueryBlockResults(ctx, height)
+	if err != nil {
+		return nil, errors.QueryFailf("GetBlockResults err").AddCause(err)
+	}
+
+	results := &model.BlockResults{
+		Height:    resp.Height,
+		TxResults: []*model.TxResult{},
+	}
+	if resp.Results == nil {
+		return results, nil
+	}
+	for _, deliverTx := range resp.Results.DeliverTx {
+		if deliverTx == nil {
+			continue
+		}
+		results.TxResults = append(results.TxResults, model.NewTxResult(*deliverTx))
+	}
+	if resp.Results.BeginBlock != nil {
+		results.BeginBlockEvents = model.NewTags(resp.Results.BeginBlock.Tags)
+	}
+	if resp.Results.EndBlock != nil {
+		results.EndBlockEvents = model.NewTags(resp.Results.EndBlock.Tags)
+	}
+	return results, nil
+}
+
 // GetBlockStatus returns the current block status from blockchain.
 func (query *Query) GetBlockStatus(ctx context.Context) (*model.BlockStatus, error) {
 	resp, err := query.transport.QueryBlockStatus(ctx)
@@ -78,3 +114,64 @@ func (query *Query) GetTx(ctx context.Context, hash []byte) (*model.BlockTx, err
 
 	return bt, nil
 }
+
+// GetUnconfirmedTxCount returns the number of transactions waiting in the mempool,
+// 0 if the mempool is empty.
+func (query *Query) GetUnconfirmedTxCount(ctx context.Context) (int, error) {
+	resp, err := query.transport.QueryNumUnconfirmedTxs(ctx)
+	if err != nil {
+		return 0, errors.QueryFailf("GetUnconfirmedTxCount err").AddCause(err)
+	}
+	return resp.N, nil
+}
+
+// IsTxInMempool returns true if the transaction with hash, as returned in
+// BroadcastResponse.CommitHash, is still waiting in the mempool.
+// Only the first maxUnconfirmedTxs pending transactions are checked.
+func (query *Query) IsTxInMempool(ctx context.Context, hash string) (bool, error) {
+	resp, err := query.transport.QueryUnconfirmedTxs(ctx, maxUnconfirmedTxs)
+	if err != nil {
+		return false, errors.QueryFailf("IsTxInMempool err").AddCause(err)
+	}
+
+	for _, tx := range resp.Txs {
+		if strings.EqualFold(hex.EncodeToString(tx.Hash()), hash) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// queryAndDecode queries key from store and decodes the response into ptr.
+// Undecodable bytes may be read in the middle of a write, so the key is read
+// once more before a DecodeFailed error with the raw bytes is returned.
+func (query *Query) queryAndDecode(ctx context.Context, key []byte, storeName string, ptr interface{}) error {
+	resp, err := query.transport.Query(ctx, key, storeName)
+	if err != nil {
+		return err
+	}
+	if err := query.transport.Cdc.UnmarshalJSON(resp, ptr); err == nil {
+		return nil
+	}
+
+	resp, err = query.transport.Query(ctx, key, storeName)
+	if err != nil {
+		return err
+	}
+	if err := query.transport.Cdc.UnmarshalJSON(resp, ptr); err != nil {
+		return errors.DecodeFailedf("failed to decode %T from store %v", ptr, storeName).AddRawData(resp).AddCause(err)
+	}
+	return nil
+}
+
+// isEmptyResponse returns true if err reports that nothing is stored under the queried key.
+func isEmptyResponse(err error) bool {
+	vErr, ok := err.(errors.Error)
+	return ok && vErr.CodeType() == errors.CodeEmptyResponse
+}
+
+// isTimeout returns true if err reports that the query was cut off by its ctx.
+func isTimeout(err error) bool {
+	vErr, ok := err.(errors.Error)
+	return ok && vErr.CodeType() == errors.CodeTimeout
+}
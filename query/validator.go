@@ -1,9 +1,16 @@
 package query
 
 import (
+	"bytes"
 	"context"
+	"encoding/hex"
+	"fmt"
+	"sort"
 
+	"github.com/lino-network/lino-go/errors"
 	"github.com/lino-network/lino-go/model"
+
+	tmtypes "github.com/tendermint/tendermint/types"
 )
 
 // GetValidator returns validator info given a validator name from blockchain.
@@ -19,6 +26,16 @@ func (query *Query) GetValidator(ctx context.Context, username string) (*model.V
 	return validator, nil
 }
 
+// GetMinValidatorDeposit returns the minimum deposit required by
+// ValidatorDeposit to become a validator.
+func (query *Query) GetMinValidatorDeposit(ctx context.Context) (model.Coin, error) {
+	param, err := query.GetValidatorParam(ctx)
+	if err != nil {
+		return model.Coin{}, err
+	}
+	return param.ValidatorMinCommitingDeposit, nil
+}
+
 // GetAllValidators returns all oncall validators from blockchain.
 func (query *Query) GetAllValidators(ctx context.Context) (*model.ValidatorList, error) {
 	resp, err := query.transport.Query(ctx, getValidatorListKey(), ValidatorKVStoreKey)
@@ -32,3 +49,339 @@ func (query *Query) GetAllValidators(ctx context.Context) (*model.ValidatorList,
 	}
 	return validatorList, nil
 }
+
+// GetAllValidatorsAtHeight returns all oncall validators at a certain height from blockchain.
+func (query *Query) GetAllValidatorsAtHeight(ctx context.Context, height int64) (*model.ValidatorList, error) {
+	resp, err := query.transport.QueryAtHeight(ctx, getValidatorListKey(), ValidatorKVStoreKey, height)
+	if err != nil {
+		return nil, err
+	}
+
+	validatorList := new(model.ValidatorList)
+	if err := query.transport.Cdc.UnmarshalJSON(resp, validatorList); err != nil {
+		return validatorList, err
+	}
+	return validatorList, nil
+}
+
+// GetPreBlockValidators returns the validators which were oncall at the
+// previous block, in the order of the validator list.
+// See model.ValidatorList for how the three validator sets differ.
+func (query *Query) GetPreBlockValidators(ctx context.Context) ([]*model.Validator, error) {
+	validatorList, err := query.GetAllValidators(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	validators := make([]*model.Validator, 0, len(validatorList.PreBlockValidators))
+	for _, username := range validatorList.PreBlockValidators {
+		validator, err := query.GetValidator(ctx, username)
+		if err != nil {
+			return nil, err
+		}
+		validators = append(validators, validator)
+	}
+	return validators, nil
+}
+
+// GetValidatorSetChanges returns the validators which joined or left
+// the oncall validator set between fromHeight and toHeight.
+func (query *Query) GetValidatorSetChanges(ctx context.Context, fromHeight, toHeight int64) (*model.ValidatorSetChanges, error) {
+	if fromHeight <= 0 || fromHeight > toHeight {
+		return nil, errors.InvalidArgf("GetValidatorSetChanges: fromHeight [%v] or toHeight [%v] is invalid", fromHeight, toHeight)
+	}
+
+	from, err := query.GetAllValidatorsAtHeight(ctx, fromHeight)
+	if err != nil {
+		return nil, err
+	}
+	to, err := query.GetAllValidatorsAtHeight(ctx, toHeight)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := &model.ValidatorSetChanges{
+		FromHeight: fromHeight,
+		ToHeight:   toHeight,
+		Added:      []string{},
+		Removed:    []string{},
+	}
+	for _, validator := range to.OncallValidators {
+		if !containsString(from.OncallValidators, validator) {
+			changes.Added = append(changes.Added, validator)
+		}
+	}
+	for _, validator := range from.OncallValidators {
+		if !containsString(to.OncallValidators, validator) {
+			changes.Removed = append(changes.Removed, validator)
+		}
+	}
+	return changes, nil
+}
+
+// SubscribeValidatorSetUpdates emits the validator list whenever the oncall validators
+// change. It listens to the validator set updates of Tendermint over a websocket of its
+// own, which is re-established if the node stops responding, and checks the list again
+// after each reconnection since updates may be missed meanwhile. A list which can't be
+// read is skipped. The channel is closed once ctx is done.
+func (query *Query) SubscribeValidatorSetUpdates(ctx context.Context) (<-chan model.ValidatorList, error) {
+	last, err := query.GetAllValidators(ctx)
+	if err != nil {
+		return nil, err
+	}
+	events, gaps, err := query.transport.SubscribeWithReconnect(ctx,
+		"lino-go-validator-set-updates", tmtypes.EventQueryValidatorSetUpdates)
+	if err != nil {
+		return nil, err
+	}
+
+	lists := make(chan model.ValidatorList, 1)
+	go func() {
+		defer close(lists)
+		for events != nil || gaps != nil {
+			select {
+			case _, ok := <-events:
+				if !ok {
+					events = nil
+					continue
+				}
+			case _, ok := <-gaps:
+				if !ok {
+					gaps = nil
+					continue
+				}
+			}
+
+			list, err := query.GetAllValidators(ctx)
+			if err != nil || sameValidators(last.OncallValidators, list.OncallValidators) {
+				continue
+			}
+			select {
+			case lists <- *list:
+				last = list
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return lists, nil
+}
+
+// CanBecomeValidator checks that a user can make a validator deposit now: the
+// user must be a voter whose stake reaches ValidatorMinVotingDeposit and whose
+// saving covers ValidatorMinCommitingDeposit, and must not be a validator already.
+// If not, the returned reason tells which requirement is missing.
+func (query *Query) CanBecomeValidator(ctx context.Context, username string) (bool, string, error) {
+	roles, err := query.GetUserStakeRoles(ctx, username)
+	if err != nil {
+		return false, "", err
+	}
+	if roles.Validator != nil {
+		return false, fmt.Sprintf("%v is already a validator", username), nil
+	}
+	if roles.Voter == nil {
+		return false, fmt.Sprintf("%v is not a voter, stake in first", username), nil
+	}
+
+	param, err := query.GetValidatorParam(ctx)
+	if err != nil {
+		return false, "", err
+	}
+	if !roles.Voter.LinoStake.IsGTE(param.ValidatorMinVotingDeposit) {
+		return false, fmt.Sprintf("stake %v of %v is less than the min voting deposit %v",
+			roles.Voter.LinoStake.CoinToLNO(), username, param.ValidatorMinVotingDeposit.CoinToLNO()), nil
+	}
+
+	bank, err := query.GetAccountBank(ctx, username)
+	if err != nil {
+		return false, "", err
+	}
+	if !bank.Saving.IsGTE(param.ValidatorMinCommitingDeposit) {
+		return false, fmt.Sprintf("saving %v of %v is less than the min committing deposit %v",
+			bank.Saving.CoinToLNO(), username, param.ValidatorMinCommitingDeposit.CoinToLNO()), nil
+	}
+	return true, "", nil
+}
+
+// GetValidatorsRanked returns all validators in the validator list sorted by
+// voting power in descending order, ties are sorted by username.
+func (query *Query) GetValidatorsRanked(ctx context.Context) ([]*model.RankedValidator, error) {
+	validatorList, err := query.GetAllValidators(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ranked := make([]*model.RankedValidator, 0, len(validatorList.AllValidators))
+	for _, username := range validatorList.AllValidators {
+		validator, err := query.GetValidator(ctx, username)
+		if err != nil {
+			return nil, err
+		}
+
+		delegatedPower := model.NewCoinFromInt64(0)
+		voter, err := query.GetVoter(ctx, username)
+		if err != nil && !isEmptyResponse(err) {
+			return nil, err
+		}
+		if voter != nil {
+			delegatedPower = voter.DelegatedPower
+		}
+
+		ranked = append(ranked, &model.RankedValidator{
+			Username:       username,
+			Deposit:        validator.Deposit,
+			DelegatedPower: delegatedPower,
+			VotingPower:    validator.Deposit.Plus(delegatedPower),
+		})
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].VotingPower.IsEqual(ranked[j].VotingPower) {
+			return ranked[i].Username < ranked[j].Username
+		}
+		return ranked[i].VotingPower.IsGT(ranked[j].VotingPower)
+	})
+	return ranked, nil
+}
+
+// GetValidatorByConsAddr returns the validator whose consensus address, as hex
+// in block signatures, is consAddr. An EmptyResponse error is returned if no
+// validator in the validator list has the address.
+func (query *Query) GetValidatorByConsAddr(ctx context.Context, consAddr string) (*model.Validator, error) {
+	addr, err := hex.DecodeString(consAddr)
+	if err != nil || len(addr) == 0 {
+		return nil, errors.InvalidArgf("GetValidatorByConsAddr: invalid consensus address %v", consAddr)
+	}
+
+	validatorList, err := query.GetAllValidators(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, username := range validatorList.AllValidators {
+		validator, err := query.GetValidator(ctx, username)
+		if err != nil {
+			return nil, err
+		}
+		if bytes.Equal(validator.Address, addr) {
+			return validator, nil
+		}
+	}
+	return nil, errors.EmptyResponsef("no validator with consensus address %v", consAddr)
+}
+
+// GetValidatorUptime returns how many of the last window committed blocks were
+// signed by the validator. Block h is signed if the validator's precommit is in
+// the last commit of block h+1, so the window ends at the block before the latest.
+func (query *Query) GetValidatorUptime(ctx context.Context, username string, window int64) (*model.ValidatorUptime, error) {
+	if window <= 0 {
+		return nil, errors.InvalidArgf("GetValidatorUptime: invalid window %v", window)
+	}
+	validator, err := query.GetValidator(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	status, err := query.transport.QueryBlockStatus(ctx)
+	if err != nil {
+		return nil, errors.QueryFailf("GetValidatorUptime: failed to get block status").AddCause(err)
+	}
+	// the first block has no last commit
+	latest := status.SyncInfo.LatestBlockHeight
+	if window > latest-1 {
+		window = latest - 1
+	}
+	if window < 0 {
+		window = 0
+	}
+
+	heights := make([]int64, 0, window)
+	for height := latest - window + 1; height <= latest; height++ {
+		heights = append(heights, height)
+	}
+	var missed int64
+	for i, resp := range query.transport.QueryBlocks(ctx, heights) {
+		if resp.Err != nil {
+			return nil, errors.QueryFailf("GetValidatorUptime: failed to get block %v", heights[i]).AddCause(resp.Err)
+		}
+		if !hasPrecommit(resp.Block.Block.LastCommit, validator.Address) {
+			missed++
+		}
+	}
+
+	uptime := &model.ValidatorUptime{
+		Username: username,
+		Window:   window,
+		Missed:   missed,
+	}
+	if window > 0 {
+		uptime.Percentage = float64(window-missed) / float64(window) * 100
+	}
+	return uptime, nil
+}
+
+// GetValidatorSlashRisk estimates how much of the validator's deposit would be
+// slashed for its absent commits, and how many more commits it can miss first.
+func (query *Query) GetValidatorSlashRisk(ctx context.Context, username string) (*model.ValidatorSlashRisk, error) {
+	validator, err := query.GetValidator(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	param, err := query.GetValidatorParam(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return slashRiskOf(validator, param), nil
+}
+
+// slashRiskOf estimates the penalty of validator for missing commits under param.
+func slashRiskOf(validator *model.Validator, param *model.ValidatorParam) *model.ValidatorSlashRisk {
+	risk := &model.ValidatorSlashRisk{
+		Username:     validator.Username,
+		AbsentCommit: validator.AbsentCommit,
+		Threshold:    param.AbsentCommitLimitation,
+		Slash:        param.PenaltyMissCommit,
+	}
+	if remaining := param.AbsentCommitLimitation - validator.AbsentCommit; remaining > 0 {
+		risk.Remaining = remaining
+	}
+	// the penalty can't take more than the deposit
+	if risk.Slash.IsGT(validator.Deposit) {
+		risk.Slash = validator.Deposit
+	}
+	return risk
+}
+
+// hasPrecommit returns true if commit contains a precommit from the
+// validator with consensus address addr.
+func hasPrecommit(commit *tmtypes.Commit, addr []byte) bool {
+	if commit == nil {
+		return false
+	}
+	for _, vote := range commit.Precommits {
+		if vote != nil && bytes.Equal(vote.ValidatorAddress, addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameValidators returns true if a and b hold the same validators in any order.
+func sameValidators(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, validator := range a {
+		if !containsString(b, validator) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsString(list []string, target string) bool {
+	for _, s := range list {
+		if s == target {
+			return true
+		}
+	}
+	return false
+}
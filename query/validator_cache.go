@@ -0,0 +1,75 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lino-network/lino-go/model"
+
+	tmtypes "github.com/tendermint/tendermint/types"
+)
+
+// ValidatorCache caches the oncall validator list of GetAllValidators, which only
+// changes at block boundaries. The list is fetched again once it is older than the
+// refresh interval, or after Invalidate, e.g. on every new block with InvalidateOnNewBlock.
+// It is safe for concurrent use.
+type ValidatorCache struct {
+	query    *Query
+	interval time.Duration
+
+	mu        sync.Mutex
+	list      *model.ValidatorList
+	fetchedAt time.Time
+}
+
+// NewValidatorCache returns an empty ValidatorCache. A cached list is fetched again
+// after interval, a non-positive interval keeps it until Invalidate is called.
+func NewValidatorCache(query *Query, interval time.Duration) *ValidatorCache {
+	return &ValidatorCache{
+		query:    query,
+		interval: interval,
+	}
+}
+
+// GetAllValidators returns the cached oncall validators, fetching them from
+// blockchain if the cache is empty or expired. The returned list is shared, so
+// it must not be modified.
+func (c *ValidatorCache) GetAllValidators(ctx context.Context) (*model.ValidatorList, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.list != nil && (c.interval <= 0 || time.Since(c.fetchedAt) < c.interval) {
+		return c.list, nil
+	}
+	list, err := c.query.GetAllValidators(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.list, c.fetchedAt = list, time.Now()
+	return list, nil
+}
+
+// Invalidate drops the cached list, the next GetAllValidators fetches it again.
+func (c *ValidatorCache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.list = nil
+}
+
+// InvalidateOnNewBlock subscribes to new blocks and invalidates the cache on each
+// of them until ctx is done, so that the list is fetched at most once per block.
+func (c *ValidatorCache) InvalidateOnNewBlock(ctx context.Context) error {
+	subscriber := fmt.Sprintf("lino-go-validator-cache-%p", c)
+	events, err := c.query.transport.Subscribe(ctx, subscriber, tmtypes.EventQueryNewBlock)
+	if err != nil {
+		return err
+	}
+	go func() {
+		for range events {
+			c.Invalidate()
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,91 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/lino-network/lino-go/model"
+)
+
+func TestSameValidators(t *testing.T) {
+	testCases := map[string]struct {
+		a      []string
+		b      []string
+		expect bool
+	}{
+		"same order": {
+			a:      []string{"val1", "val2"},
+			b:      []string{"val1", "val2"},
+			expect: true,
+		},
+		"different order": {
+			a:      []string{"val1", "val2"},
+			b:      []string{"val2", "val1"},
+			expect: true,
+		},
+		"validator replaced": {
+			a:      []string{"val1", "val2"},
+			b:      []string{"val1", "val3"},
+			expect: false,
+		},
+		"validator added": {
+			a:      []string{"val1"},
+			b:      []string{"val1", "val2"},
+			expect: false,
+		},
+		"both empty": {
+			expect: true,
+		},
+	}
+
+	for testName, tc := range testCases {
+		if got := sameValidators(tc.a, tc.b); got != tc.expect {
+			t.Errorf("%s: diff result, got %v, want %v", testName, got, tc.expect)
+		}
+	}
+}
+
+func TestSlashRiskOf(t *testing.T) {
+	param := &model.ValidatorParam{
+		PenaltyMissCommit:      model.NewCoinFromInt64(200),
+		AbsentCommitLimitation: 600,
+	}
+	testCases := map[string]struct {
+		validator       model.Validator
+		expectRemaining int64
+		expectSlash     model.Coin
+	}{
+		"no absent commit": {
+			validator:       model.Validator{Deposit: model.NewCoinFromInt64(1000)},
+			expectRemaining: 600,
+			expectSlash:     model.NewCoinFromInt64(200),
+		},
+		"close to the threshold": {
+			validator:       model.Validator{Deposit: model.NewCoinFromInt64(1000), AbsentCommit: 590},
+			expectRemaining: 10,
+			expectSlash:     model.NewCoinFromInt64(200),
+		},
+		"at the threshold": {
+			validator:       model.Validator{Deposit: model.NewCoinFromInt64(1000), AbsentCommit: 600},
+			expectRemaining: 0,
+			expectSlash:     model.NewCoinFromInt64(200),
+		},
+		"deposit less than penalty": {
+			validator:       model.Validator{Deposit: model.NewCoinFromInt64(50), AbsentCommit: 1},
+			expectRemaining: 599,
+			expectSlash:     model.NewCoinFromInt64(50),
+		},
+	}
+
+	for testName, tc := range testCases {
+		risk := slashRiskOf(&tc.validator, param)
+		if risk.Threshold != 600 || risk.AbsentCommit != tc.validator.AbsentCommit {
+			t.Errorf("%s: diff risk, got %+v", testName, risk)
+		}
+		if risk.Remaining != tc.expectRemaining {
+			t.Errorf("%s: diff remaining, got %v, want %v", testName, risk.Remaining, tc.expectRemaining)
+		}
+		if !risk.Slash.IsEqual(tc.expectSlash) {
+			t.Errorf("%s: diff slash, got %v, want %v", testName, risk.Slash, tc.expectSlash)
+		}
+	}
+}
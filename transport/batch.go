@@ -0,0 +1,94 @@
+package transport
+
+import (
+	"context"
+	"sync"
+
+	"github.com/lino-network/lino-go/errors"
+
+	cmn "github.com/tendermint/tendermint/libs/common"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+)
+
+// DefaultMaxConcurrentQueries is the number of queries QueryBatch sends
+// at the same time if Transport.MaxConcurrentQueries is not set.
+const DefaultMaxConcurrentQueries = 10
+
+// QueryRequest is a single key query in a batch.
+type QueryRequest struct {
+	Key       cmn.HexBytes
+	StoreName string
+	// Height is the block height to query at, 0 for the latest block.
+	Height int64
+}
+
+// QueryResponse is the result of a QueryRequest.
+type QueryResponse struct {
+	Value []byte
+	Err   error
+}
+
+// QueryBatch issues requests concurrently, at most MaxConcurrentQueries at a time,
+// and returns one response per request in the same order. A failed request is
+// reported in its response and doesn't affect the others. The returned error is
+// a Timeout error if ctx is done before all requests finish.
+func (t Transport) QueryBatch(ctx context.Context, requests []QueryRequest) ([]QueryResponse, error) {
+	responses := make([]QueryResponse, len(requests))
+	t.runBatch(ctx, len(requests), func(i int) {
+		responses[i].Value, responses[i].Err = t.QueryAtHeight(ctx, requests[i].Key, requests[i].StoreName, requests[i].Height)
+	}, func(i int, err error) {
+		responses[i].Err = err
+	})
+
+	if ctx.Err() != nil {
+		return responses, errors.Timeout("query batch timeout").AddCause(ctx.Err())
+	}
+	return responses, nil
+}
+
+// BlockResponse is the result of querying the block at a height.
+type BlockResponse struct {
+	Block *ctypes.ResultBlock
+	Err   error
+}
+
+// QueryBlocks queries the blocks at heights concurrently with the same limit
+// as QueryBatch and returns one response per height in the same order.
+// A failed query, e.g. because ctx is done, is reported in its response.
+func (t Transport) QueryBlocks(ctx context.Context, heights []int64) []BlockResponse {
+	responses := make([]BlockResponse, len(heights))
+	t.runBatch(ctx, len(heights), func(i int) {
+		responses[i].Block, responses[i].Err = t.QueryBlock(ctx, heights[i])
+	}, func(i int, err error) {
+		responses[i].Err = err
+	})
+	return responses
+}
+
+// runBatch calls run for 0 to n-1 concurrently, at most MaxConcurrentQueries
+// at a time. Calls still waiting for their turn when ctx is done get a Timeout
+// error through skip instead.
+func (t Transport) runBatch(ctx context.Context, n int, run func(i int), skip func(i int, err error)) {
+	maxConcurrency := t.MaxConcurrentQueries
+	if maxConcurrency <= 0 {
+		maxConcurrency = DefaultMaxConcurrentQueries
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrency)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				skip(i, errors.Timeout("query batch timeout").AddCause(ctx.Err()))
+				return
+			}
+			defer func() { <-sem }()
+			run(i)
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,71 @@
+package transport
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lino-network/lino-go/errors"
+
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	tmtypes "github.com/tendermint/tendermint/types"
+)
+
+func TestQueryBatch(t *testing.T) {
+	// without a node every request fails, which is reported per request
+	transport := Transport{MaxConcurrentQueries: 2}
+	requests := make([]QueryRequest, 5)
+	for i := range requests {
+		requests[i] = QueryRequest{Key: []byte{byte(i)}, StoreName: "account"}
+	}
+
+	responses, err := transport.QueryBatch(context.Background(), requests)
+	if err != nil {
+		t.Fatalf("failed to query batch, got err %v", err)
+	}
+	if len(responses) != len(requests) {
+		t.Fatalf("diff number of responses, got %v, want %v", len(responses), len(requests))
+	}
+	for i, resp := range responses {
+		vErr, ok := resp.Err.(errors.Error)
+		if !ok || vErr.CodeType() != errors.CodeNodeNotConfigured {
+			t.Errorf("response %v: diff err, got %v", i, resp.Err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := transport.QueryBatch(ctx, requests); err == nil {
+		t.Errorf("expect error when ctx is done")
+	}
+}
+
+// blockClient returns a block with the requested height, other calls panic.
+type blockClient struct {
+	rpcclient.Client
+}
+
+func (c blockClient) Block(height *int64) (*ctypes.ResultBlock, error) {
+	block := &ctypes.ResultBlock{Block: &tmtypes.Block{}}
+	block.Block.Header.Height = *height
+	return block, nil
+}
+
+func TestQueryBlocks(t *testing.T) {
+	transport := Transport{client: blockClient{}, MaxConcurrentQueries: 2}
+	heights := []int64{3, 1, 2}
+
+	responses := transport.QueryBlocks(context.Background(), heights)
+	if len(responses) != len(heights) {
+		t.Fatalf("diff number of responses, got %v, want %v", len(responses), len(heights))
+	}
+	for i, resp := range responses {
+		if resp.Err != nil {
+			t.Errorf("height %v: failed to query block, got err %v", heights[i], resp.Err)
+			continue
+		}
+		if resp.Block.Block.Header.Height != heights[i] {
+			t.Errorf("diff height, got %v, want %v", resp.Block.Block.Header.Height, heights[i])
+		}
+	}
+}
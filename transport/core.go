@@ -4,7 +4,9 @@ package transport
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/cosmos/cosmos-sdk/wire"
 	"github.com/lino-network/lino-go/errors"
@@ -12,17 +14,89 @@ import (
 	"github.com/spf13/viper"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
+	abci "github.com/tendermint/tendermint/abci/types"
+	crypto "github.com/tendermint/tendermint/crypto"
 	cmn "github.com/tendermint/tendermint/libs/common"
 	rpcclient "github.com/tendermint/tendermint/rpc/client"
 	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	tmtypes "github.com/tendermint/tendermint/types"
 )
 
+// maxSignatureSize is the size of the largest signature, a DER encoded secp256k1 signature.
+const maxSignatureSize = 72
+
+// txInCacheErrMsg is returned by the node when the same transaction is already in the mempool.
+const txInCacheErrMsg = "Tx already exists in cache"
+
+// BeforeBroadcastFunc is called with the hash of a signed transaction
+// before it is sent to the node. The hash is the upper case hex string the
+// blockchain assigns to the transaction, same as BroadcastResponse.CommitHash.
+// Returning an error aborts the broadcast.
+type BeforeBroadcastFunc func(txHash string) error
+
+// BroadcastMode selects how long broadcasting a transaction waits for the node.
+type BroadcastMode int
+
+const (
+	// BroadcastAsync returns once the node has received the transaction, before
+	// CheckTx, so the result only holds the hash of the transaction.
+	BroadcastAsync BroadcastMode = iota + 1
+	// BroadcastSync returns the CheckTx result, once the transaction is in the mempool.
+	BroadcastSync
+	// BroadcastCommit returns the CheckTx and DeliverTx results, once the
+	// transaction is committed in a block.
+	BroadcastCommit
+)
+
+func (mode BroadcastMode) String() string {
+	switch mode {
+	case BroadcastAsync:
+		return "async"
+	case BroadcastSync:
+		return "sync"
+	case BroadcastCommit:
+		return "commit"
+	default:
+		return fmt.Sprintf("BroadcastMode(%d)", int(mode))
+	}
+}
+
+// broadcastModeOf returns the mode of the deprecated checkTxOnly argument.
+func broadcastModeOf(checkTxOnly bool) BroadcastMode {
+	if checkTxOnly {
+		return BroadcastSync
+	}
+	return BroadcastCommit
+}
+
 // Transport is a wrapper of tendermint rpc client and codec.
 type Transport struct {
 	chainId string
 	nodeUrl string
 	client  rpcclient.Client
 	Cdc     *wire.Codec
+	// ProposalCdc decodes proposals, see MakeProposalCodec.
+	ProposalCdc *wire.Codec
+
+	// BeforeBroadcast, if set, is called before every signed transaction
+	// is broadcast, e.g. to write the hash to an audit log.
+	BeforeBroadcast BeforeBroadcastFunc
+
+	// MaxConcurrentQueries bounds the queries QueryBatch sends at the same time,
+	// DefaultMaxConcurrentQueries if not positive.
+	MaxConcurrentQueries int
+
+	// RateLimiter, if set, throttles every RPC call to the node. Calls block
+	// until they are allowed or their ctx is done.
+	RateLimiter *RateLimiter
+
+	// SignBytesEncoding is the encoding of the sign bytes, SignBytesAminoJSON by default.
+	SignBytesEncoding SignBytesEncoding
+
+	// MaxTxBytes, if positive, is the max size of a transaction accepted by the
+	// node. A larger transaction is rejected with a TxTooLarge error before it
+	// is broadcast, see EstimateTxSize to split a batch beforehand.
+	MaxTxBytes int
 }
 
 // NewTransportFromConfig initiates an instance of Transport from config files.
@@ -40,10 +114,11 @@ func NewTransportFromConfig() *Transport {
 	}
 	rpc := rpcclient.NewHTTP(nodeUrl, "/websocket")
 	return &Transport{
-		chainId: v.GetString("chain_id"),
-		nodeUrl: nodeUrl,
-		client:  rpc,
-		Cdc:     MakeCodec(),
+		chainId:     v.GetString("chain_id"),
+		nodeUrl:     nodeUrl,
+		client:      rpc,
+		Cdc:         MakeCodec(),
+		ProposalCdc: MakeProposalCodec(),
 	}
 }
 
@@ -54,65 +129,112 @@ func NewTransportFromArgs(chainID, nodeUrl string) *Transport {
 	}
 	rpc := rpcclient.NewHTTP(nodeUrl, "/websocket")
 	return &Transport{
-		chainId: chainID,
-		nodeUrl: nodeUrl,
-		client:  rpc,
-		Cdc:     MakeCodec(),
+		chainId:     chainID,
+		nodeUrl:     nodeUrl,
+		client:      rpc,
+		Cdc:         MakeCodec(),
+		ProposalCdc: MakeProposalCodec(),
 	}
 }
 
-// Query from Tendermint with the provided key and storename
-func (t Transport) Query(ctx context.Context, key cmn.HexBytes, storeName string) (res []byte, err error) {
-	finishChan := make(chan bool)
-	go func() {
-		res, err = t.query(key, storeName, "key", 0)
-		finishChan <- true
-	}()
-
-	select {
-	case <-finishChan:
-		break
-	case <-ctx.Done():
-		return nil, errors.Timeout("query timeout").AddCause(ctx.Err())
+// NewTransportFromClient initiates an instance of Transport that calls the node
+// through client, e.g. a client wrapping another one or a fake in tests.
+func NewTransportFromClient(chainID string, client rpcclient.Client) *Transport {
+	return &Transport{
+		chainId:     chainID,
+		client:      client,
+		Cdc:         MakeCodec(),
+		ProposalCdc: MakeProposalCodec(),
 	}
+}
 
-	return res, err
+// ChainID returns the chain ID transactions are signed for.
+func (t Transport) ChainID() string {
+	return t.chainId
 }
 
-// Query from Tendermint with the provided key and storename at certain height
-func (t Transport) QueryAtHeight(ctx context.Context, key cmn.HexBytes, storeName string, height int64) (res []byte, err error) {
-	finishChan := make(chan bool)
-	go func() {
-		res, err = t.query(key, storeName, "key", height)
-		finishChan <- true
+// CheckChainID returns a ChainIDMismatch error if the chain ID of the node
+// differs from the configured one, in which case all transactions signed by
+// this transport would be rejected.
+func (t Transport) CheckChainID(ctx context.Context) error {
+	status, err := t.QueryBlockStatus(ctx)
+	if err != nil {
+		return errors.QueryFailf("failed to get chain ID of node %v", t.nodeUrl).AddCause(err)
+	}
+	if status.NodeInfo.Network != t.chainId {
+		return errors.ChainIDMismatchf("configured chain ID %q, node %v is on chain %q",
+			t.chainId, t.nodeUrl, status.NodeInfo.Network)
+	}
+	return nil
+}
+
+// RegisterMsg registers a message type under name, so that it can be
+// broadcast and decoded by this transport, e.g. a message added by a chain upgrade.
+func (t Transport) RegisterMsg(name string, msg model.Msg) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.InvalidArgf("failed to register msg %v: %v", name, r)
+		}
 	}()
+	t.Cdc.RegisterConcrete(msg, name, nil)
+	return nil
+}
 
-	select {
-	case <-finishChan:
-		break
-	case <-ctx.Done():
-		return nil, errors.Timeoutf("query at height %v timeout", height).AddCause(ctx.Err())
-	}
+// IsMsgRegistered returns true if a message type is registered under name,
+// by MakeCodec or RegisterMsg.
+func (t Transport) IsMsgRegistered(name string) bool {
+	msg, err := t.decodeMsg(name, []byte("{}"))
+	return err == nil && msg != nil
+}
 
-	return res, err
+// DecodeJSONMsg decodes the amino JSON of a message of the type registered under
+// msgType, i.e. the "value" of a message in a transaction. An unregistered msgType
+// is an InvalidArg error.
+func (t Transport) DecodeJSONMsg(msgType string, jsonBytes []byte) (model.Msg, error) {
+	if !t.IsMsgRegistered(msgType) {
+		return nil, errors.InvalidArgf("msg type %v is not registered", msgType)
+	}
+	msg, err := t.decodeMsg(msgType, jsonBytes)
+	if err != nil {
+		return nil, errors.InvalidArgf("failed to decode msg %v", msgType).AddCause(err)
+	}
+	return msg, nil
 }
 
-// Query from Tendermint with the provided subspace and storename
-func (t Transport) QuerySubspace(ctx context.Context, subspace []byte, storeName string) (res []sdk.KVPair, err error) {
-	var resRaw []byte
-	finishChan := make(chan bool)
-	go func() {
-		resRaw, err = t.query(subspace, storeName, "subspace", 0)
-		finishChan <- true
+func (t Transport) decodeMsg(msgType string, jsonBytes []byte) (msg model.Msg, err error) {
+	defer func() {
+		// amino panics if the type registered under msgType is not a model.Msg
+		if r := recover(); r != nil {
+			msg, err = nil, fmt.Errorf("%v is not a msg: %v", msgType, r)
+		}
 	}()
-
-	select {
-	case <-finishChan:
-		break
-	case <-ctx.Done():
-		return nil, errors.Timeout("query subspace timeout").AddCause(ctx.Err())
+	wrapped, err := json.Marshal(struct {
+		Type  string          `json:"type"`
+		Value json.RawMessage `json:"value"`
+	}{msgType, json.RawMessage(jsonBytes)})
+	if err != nil {
+		return nil, err
+	}
+	if err := t.Cdc.UnmarshalJSON(wrapped, &msg); err != nil {
+		return nil, err
 	}
+	return msg, nil
+}
+
+// Query from Tendermint with the provided key and storename
+func (t Transport) Query(ctx context.Context, key cmn.HexBytes, storeName string) ([]byte, error) {
+	return t.query(ctx, key, storeName, "key", 0)
+}
+
+// Query from Tendermint with the provided key and storename at certain height
+func (t Transport) QueryAtHeight(ctx context.Context, key cmn.HexBytes, storeName string, height int64) ([]byte, error) {
+	return t.query(ctx, key, storeName, "key", height)
+}
 
+// Query from Tendermint with the provided subspace and storename.
+// Cancelling ctx returns at once, also in the middle of a large scan.
+func (t Transport) QuerySubspace(ctx context.Context, subspace []byte, storeName string) (res []sdk.KVPair, err error) {
+	resRaw, err := t.query(ctx, subspace, storeName, "subspace", 0)
 	if err != nil {
 		return nil, err
 	}
@@ -121,36 +243,73 @@ func (t Transport) QuerySubspace(ctx context.Context, subspace []byte, storeName
 	return
 }
 
-func (t Transport) query(key cmn.HexBytes, storeName, endPath string, height int64) (res []byte, err error) {
-	path := fmt.Sprintf("/store/%s/%s", storeName, endPath)
+// QueryPath sends an ABCI query with data to an arbitrary path at a certain height,
+// 0 for the latest block, e.g. a custom querier of the blockchain under "/custom/...".
+// Query, QueryAtHeight and QuerySubspace are shortcuts for the "/store/..." paths.
+func (t Transport) QueryPath(ctx context.Context, path string, data []byte, height int64) ([]byte, error) {
+	return t.queryPath(ctx, path, data, height)
+}
+
+func (t Transport) query(ctx context.Context, key cmn.HexBytes, storeName, endPath string, height int64) ([]byte, error) {
+	return t.queryPath(ctx, fmt.Sprintf("/store/%s/%s", storeName, endPath), key, height)
+}
+
+type queryResult struct {
+	res []byte
+	err error
+}
+
+// queryPath sends the ABCI query through the rpc client and returns once it
+// completes or ctx is done. The rpc client takes no ctx, so an abandoned query
+// finishes in the background, the buffered channel lets its goroutine exit.
+func (t Transport) queryPath(ctx context.Context, path string, data []byte, height int64) ([]byte, error) {
+	if err := t.throttle(ctx); err != nil {
+		return nil, err
+	}
 	node, err := t.GetNode()
 	if err != nil {
-		return res, err
+		return nil, err
 	}
 
-	opts := rpcclient.ABCIQueryOptions{
-		Height:  height,
-		Trusted: true,
-	}
-	result, err := node.ABCIQueryWithOptions(path, key, opts)
-	if err != nil {
-		return res, err
+	resultChan := make(chan queryResult, 1)
+	go func() {
+		opts := rpcclient.ABCIQueryOptions{
+			Height:  height,
+			Trusted: true,
+		}
+		result, err := node.ABCIQueryWithOptions(path, data, opts)
+		if err != nil {
+			resultChan <- queryResult{err: err}
+			return
+		}
+		res, err := queryResponseValue(result.Response)
+		resultChan <- queryResult{res: res, err: err}
+	}()
+
+	select {
+	case result := <-resultChan:
+		return result.res, result.err
+	case <-ctx.Done():
+		return nil, errors.Timeoutf("query %v timeout", path).AddCause(ctx.Err())
 	}
+}
 
-	resp := result.Response
+// queryResponseValue returns the value of a successful ABCI query.
+func queryResponseValue(resp abci.ResponseQuery) ([]byte, error) {
 	if resp.Code != uint32(0) {
-		return res, errors.QueryFail("Query failed").AddBlockChainCode(resp.Code).AddBlockChainLog(resp.Log)
+		return nil, errors.QueryFail("Query failed").AddBlockChainCode(resp.Code).AddBlockChainLog(resp.Log)
 	}
-
-	if resp.Value == nil || len(resp.Value) == 0 {
+	if len(resp.Value) == 0 {
 		return nil, errors.EmptyResponse("Empty response!")
 	}
-
 	return resp.Value, nil
 }
 
 // QueryBlock queries a block with a certain height from blockchain.
 func (t Transport) QueryBlock(ctx context.Context, height int64) (res *ctypes.ResultBlock, err error) {
+	if err := t.throttle(ctx); err != nil {
+		return nil, err
+	}
 	node, err := t.GetNode()
 	if err != nil {
 		return res, err
@@ -172,8 +331,39 @@ func (t Transport) QueryBlock(ctx context.Context, height int64) (res *ctypes.Re
 	return res, err
 }
 
+// QueryBlockResults queries the ABCI results of the block with a certain height from blockchain.
+func (t Transport) QueryBlockResults(ctx context.Context, height int64) (*ctypes.ResultBlockResults, error) {
+	if err := t.throttle(ctx); err != nil {
+		return nil, err
+	}
+	node, err := t.GetNode()
+	if err != nil {
+		return nil, err
+	}
+
+	type result struct {
+		res *ctypes.ResultBlockResults
+		err error
+	}
+	resultChan := make(chan result, 1)
+	go func() {
+		res, err := node.BlockResults(&height)
+		resultChan <- result{res: res, err: err}
+	}()
+
+	select {
+	case r := <-resultChan:
+		return r.res, r.err
+	case <-ctx.Done():
+		return nil, errors.Timeout("query block results timeout").AddCause(ctx.Err())
+	}
+}
+
 // QueryBlockStatus queries block status from blockchain.
 func (t Transport) QueryBlockStatus(ctx context.Context) (res *ctypes.ResultStatus, err error) {
+	if err := t.throttle(ctx); err != nil {
+		return nil, err
+	}
 	node, err := t.GetNode()
 	if err != nil {
 		return res, err
@@ -197,6 +387,9 @@ func (t Transport) QueryBlockStatus(ctx context.Context) (res *ctypes.ResultStat
 
 // QueryTx queries tx from blockchain.
 func (t Transport) QueryTx(ctx context.Context, hash []byte) (res *ctypes.ResultTx, err error) {
+	if err := t.throttle(ctx); err != nil {
+		return nil, err
+	}
 	node, err := t.GetNode()
 	if err != nil {
 		return res, err
@@ -218,53 +411,255 @@ func (t Transport) QueryTx(ctx context.Context, hash []byte) (res *ctypes.Result
 	return res, err
 }
 
-// BroadcastTx broadcasts a transcation to blockchain.
-func (t Transport) BroadcastTx(tx []byte, checkTxOnly bool) (interface{}, error) {
+// QueryUnconfirmedTxs queries at most limit transactions in the mempool of the node.
+func (t Transport) QueryUnconfirmedTxs(ctx context.Context, limit int) (*ctypes.ResultUnconfirmedTxs, error) {
+	if err := t.throttle(ctx); err != nil {
+		return nil, err
+	}
+	node, err := t.getMempoolNode()
+	if err != nil {
+		return nil, err
+	}
+
+	type result struct {
+		res *ctypes.ResultUnconfirmedTxs
+		err error
+	}
+	resultChan := make(chan result, 1)
+	go func() {
+		res, err := node.UnconfirmedTxs(limit)
+		resultChan <- result{res: res, err: err}
+	}()
+
+	select {
+	case r := <-resultChan:
+		return r.res, r.err
+	case <-ctx.Done():
+		return nil, errors.Timeout("query unconfirmed txs timeout").AddCause(ctx.Err())
+	}
+}
+
+// QueryNumUnconfirmedTxs queries the number of transactions in the mempool of the node.
+func (t Transport) QueryNumUnconfirmedTxs(ctx context.Context) (*ctypes.ResultUnconfirmedTxs, error) {
+	if err := t.throttle(ctx); err != nil {
+		return nil, err
+	}
+	node, err := t.getMempoolNode()
+	if err != nil {
+		return nil, err
+	}
+
+	type result struct {
+		res *ctypes.ResultUnconfirmedTxs
+		err error
+	}
+	resultChan := make(chan result, 1)
+	go func() {
+		res, err := node.NumUnconfirmedTxs()
+		resultChan <- result{res: res, err: err}
+	}()
+
+	select {
+	case r := <-resultChan:
+		return r.res, r.err
+	case <-ctx.Done():
+		return nil, errors.Timeout("query num unconfirmed txs timeout").AddCause(ctx.Err())
+	}
+}
+
+// BroadcastTx broadcasts a transcation to blockchain, in sync mode if
+// checkTxOnly and in commit mode otherwise.
+//
+// Deprecated: use BroadcastTxWithMode.
+func (t Transport) BroadcastTx(ctx context.Context, tx []byte, checkTxOnly bool) (interface{}, error) {
+	return t.BroadcastTxWithMode(ctx, tx, broadcastModeOf(checkTxOnly))
+}
+
+// BroadcastTxWithMode broadcasts a transcation to blockchain in mode, see
+// BroadcastMode. ctx bounds the wait for the RateLimiter, the rpc call itself takes no ctx.
+func (t Transport) BroadcastTxWithMode(ctx context.Context, tx []byte, mode BroadcastMode) (interface{}, error) {
+	if t.MaxTxBytes > 0 && len(tx) > t.MaxTxBytes {
+		return nil, errors.TxTooLargef("tx of %v bytes exceeds the max of %v bytes", len(tx), t.MaxTxBytes)
+	}
 	node, err := t.GetNode()
 	if err != nil {
 		return nil, err
 	}
+	if err := t.throttle(ctx); err != nil {
+		return nil, err
+	}
 
-	if checkTxOnly {
-		return node.BroadcastTxSync(tx)
+	var res interface{}
+	switch mode {
+	case BroadcastAsync:
+		res, err = node.BroadcastTxAsync(tx)
+	case BroadcastSync:
+		res, err = node.BroadcastTxSync(tx)
+	case BroadcastCommit:
+		res, err = node.BroadcastTxCommit(tx)
+	default:
+		return nil, errors.InvalidArgf("unknown broadcast mode %v", mode)
+	}
+	if err != nil && strings.Contains(err.Error(), txInCacheErrMsg) {
+		// the same transaction has been broadcast before and is still pending.
+		// It passed CheckTx then, so an async or sync broadcast succeeds, while
+		// a commit broadcast has no block to report and returns TxAlreadyPending
+		// with the transaction as raw data, to wait for it by its hash.
+		if mode != BroadcastCommit {
+			return &ctypes.ResultBroadcastTx{Hash: cmn.HexBytes(tmtypes.Tx(tx).Hash())}, nil
+		}
+		return nil, errors.TxAlreadyPendingf("tx %v is already in the mempool", TxHash(tx)).AddRawData(tx)
 	}
-	return node.BroadcastTxCommit(tx)
+	return res, err
 }
 
 // SignBuildBroadcast signs msg with private key and then broadcasts
-// the transaction to blockchain.
-func (t Transport) SignBuildBroadcast(msg model.Msg, privKeyHex string, seq int64, memo string, checkTxOnly bool) (interface{}, error) {
-	msgs := []model.Msg{msg}
+// the transaction to blockchain, in sync mode if checkTxOnly and in commit mode otherwise.
+//
+// Deprecated: use SignBuildBroadcastWithMode.
+func (t Transport) SignBuildBroadcast(ctx context.Context, msg model.Msg, privKeyHex string, seq int64, memo string, checkTxOnly bool) (interface{}, error) {
+	signer, err := NewSignerFromHex(privKeyHex)
+	if err != nil {
+		return nil, err
+	}
+	return t.SignBuildBroadcastWithMode(ctx, msg, signer, seq, memo, broadcastModeOf(checkTxOnly))
+}
+
+// SignBuildBroadcastWithSigner signs msg with signer and then broadcasts
+// the transaction to blockchain, in sync mode if checkTxOnly and in commit mode otherwise.
+//
+// Deprecated: use SignBuildBroadcastWithMode.
+func (t Transport) SignBuildBroadcastWithSigner(ctx context.Context, msg model.Msg, signer Signer, seq int64, memo string, checkTxOnly bool) (interface{}, error) {
+	return t.SignBuildBroadcastWithMode(ctx, msg, signer, seq, memo, broadcastModeOf(checkTxOnly))
+}
 
-	privKey, err := GetPrivKeyFromHex(privKeyHex)
+// SignBuildBroadcastWithMode signs msg with signer and then broadcasts
+// the transaction to blockchain in mode, see BroadcastMode.
+func (t Transport) SignBuildBroadcastWithMode(ctx context.Context, msg model.Msg, signer Signer, seq int64, memo string, mode BroadcastMode) (interface{}, error) {
+	txByte, err := t.SignBuild(msg, signer, seq, memo)
 	if err != nil {
 		return nil, err
 	}
 
-	signMsgBytes, err := EncodeSignMsg(t.Cdc, msgs, t.chainId, seq)
+	if t.BeforeBroadcast != nil {
+		txHash := TxHash(txByte)
+		if err := t.BeforeBroadcast(txHash); err != nil {
+			return nil, errors.FailedToBroadcastf("abort broadcasting tx %v", txHash).AddCause(err)
+		}
+	}
+
+	// broadcast
+	return t.BroadcastTxWithMode(ctx, txByte, mode)
+}
+
+// SignBuild signs msg with signer and returns the transaction bytes
+// ready to be broadcast.
+func (t Transport) SignBuild(msg model.Msg, signer Signer, seq int64, memo string) ([]byte, error) {
+	msgs := []model.Msg{msg}
+
+	signMsgBytes, err := EncodeSignMsgWithEncoding(t.Cdc, msgs, t.chainId, seq, t.SignBytesEncoding)
 	if err != nil {
 		return nil, err
 	}
 	// SignatureFromBytes
-	sig, err := privKey.Sign(signMsgBytes)
+	sig, err := signer.Sign(signMsgBytes)
 	if err != nil {
 		return nil, err
 	}
 
 	// build transaction bytes
-	txByte, err := EncodeTx(t.Cdc, msgs, privKey.PubKey(), sig, seq, memo)
+	return buildSignedTx(t.Cdc, msgs, signMsgBytes, signer.PubKey(), sig, seq, memo)
+}
+
+// BuildSignedTx assembles the transaction of msg from sig, a detached signature
+// of the sign bytes of msg (see GetSignBytes) made by the private key of pubKey,
+// e.g. by an HSM which only returns signatures. pubKey is given explicitly, so it
+// is checked against sig: a WrongSigningKey error is returned if it doesn't verify.
+func (t Transport) BuildSignedTx(msg model.Msg, pubKey crypto.PubKey, sig []byte, seq int64, memo string) ([]byte, error) {
+	msgs := []model.Msg{msg}
+	signMsgBytes, err := EncodeSignMsgWithEncoding(t.Cdc, msgs, t.chainId, seq, t.SignBytesEncoding)
 	if err != nil {
 		return nil, err
 	}
+	return buildSignedTx(t.Cdc, msgs, signMsgBytes, pubKey, sig, seq, memo)
+}
 
-	// broadcast
-	return t.BroadcastTx(txByte, checkTxOnly)
+// EstimateTxSize returns an upper bound of the size of the transaction of msg
+// signed by the private key of pubKey, to compare with MaxTxBytes before signing.
+// The size of a signature varies, the bound assumes the largest one.
+func (t Transport) EstimateTxSize(msg model.Msg, pubKey crypto.PubKey, seq int64, memo string) (int, error) {
+	txBytes, err := EncodeTx(t.Cdc, []model.Msg{msg}, pubKey, make([]byte, maxSignatureSize), seq, memo)
+	if err != nil {
+		return 0, err
+	}
+	return len(txBytes), nil
+}
+
+// buildSignedTx encodes the transaction after checking that sig is a
+// signature of signMsgBytes by pubKey.
+func buildSignedTx(cdc *wire.Codec, msgs []model.Msg, signMsgBytes []byte,
+	pubKey crypto.PubKey, sig []byte, seq int64, memo string) ([]byte, error) {
+	if pubKey == nil {
+		return nil, errors.InvalidArgf("no public key to verify the signature")
+	}
+	if !pubKey.VerifyBytes(signMsgBytes, sig) {
+		return nil, errors.WrongSigningKeyf("signature doesn't match public key %X", pubKey.Bytes())
+	}
+	return EncodeTx(cdc, msgs, pubKey, sig, seq, memo)
+}
+
+// GetSignBytes returns the bytes to be signed for msg on chain chainId with
+// sequence number seq, exactly as produced by EncodeSignMsgWithEncoding with
+// t.SignBytesEncoding. By default the bytes are the amino JSON of model.SignMsg
+// with all object keys sorted alphabetically and no insignificant whitespace:
+//
+//	{"account_number":"0","chain_id":"<chainId>","fee":<ZeroFee>,"memo":"","msgs":[<msg>],"sequence":"<seq>"}
+//
+// where <msg> is the amino JSON of msg ({"type":"<registered name>","value":{...}})
+// with sorted keys, and int64 values are encoded as strings. The memo is not
+// part of the sign bytes. The signature over these bytes can be assembled into
+// a transaction with EncodeTx.
+func (t Transport) GetSignBytes(msg model.Msg, chainId string, seq int64) ([]byte, error) {
+	return EncodeSignMsgWithEncoding(t.Cdc, []model.Msg{msg}, chainId, seq, t.SignBytesEncoding)
+}
+
+// Close stops the websocket connection of the node client if it has been
+// started, e.g. by a subscription. It is safe to call on a transport that
+// never opened a websocket, and more than once.
+func (t Transport) Close() error {
+	if t.client == nil || !t.client.IsRunning() {
+		return nil
+	}
+	if err := t.client.Stop(); err != nil && err != cmn.ErrAlreadyStopped {
+		return err
+	}
+	return nil
 }
 
 // GetNote returns the Tendermint rpc client node.
 func (t Transport) GetNode() (rpcclient.Client, error) {
 	if t.client == nil {
-		return nil, errors.InvalidNodeURL("Must define node URL")
+		return nil, errors.NodeNotConfigured("Must define node URL")
 	}
 	return t.client, nil
 }
+
+func (t Transport) getMempoolNode() (rpcclient.MempoolClient, error) {
+	node, err := t.GetNode()
+	if err != nil {
+		return nil, err
+	}
+	mempoolNode, ok := node.(rpcclient.MempoolClient)
+	if !ok {
+		return nil, errors.QueryFail("node client doesn't support mempool queries")
+	}
+	return mempoolNode, nil
+}
+
+// throttle waits for RateLimiter, if set, before an RPC call.
+func (t Transport) throttle(ctx context.Context) error {
+	if t.RateLimiter == nil {
+		return nil
+	}
+	return t.RateLimiter.Wait(ctx)
+}
@@ -0,0 +1,564 @@
+package transport
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lino-network/lino-go/errors"
+	"github.com/lino-network/lino-go/model"
+
+	crypto "github.com/tendermint/tendermint/crypto"
+	"github.com/tendermint/tendermint/crypto/secp256k1"
+	cmn "github.com/tendermint/tendermint/libs/common"
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	tmtypes "github.com/tendermint/tendermint/types"
+)
+
+type customMsg struct {
+	Username string `json:"username"`
+	Value    int64  `json:"value"`
+}
+
+func (msg customMsg) Type() string         { return "custom" }
+func (msg customMsg) ValidateBasic() error { return nil }
+
+func TestRegisterMsg(t *testing.T) {
+	transport := NewTransportFromArgs("test-chain", "")
+	if err := transport.RegisterMsg("lino/custom", customMsg{}); err != nil {
+		t.Fatalf("failed to register msg, got err %v", err)
+	}
+	if err := transport.RegisterMsg("lino/custom", customMsg{}); err == nil {
+		t.Errorf("expect error when registering msg twice")
+	}
+
+	tx := model.Transaction{
+		Msgs: []model.Msg{customMsg{Username: "user1", Value: 10}},
+		Fee:  ZeroFee,
+	}
+	bz, err := transport.Cdc.MarshalJSON(tx)
+	if err != nil {
+		t.Fatalf("failed to marshal tx, got err %v", err)
+	}
+
+	var decoded model.Transaction
+	if err := transport.Cdc.UnmarshalJSON(bz, &decoded); err != nil {
+		t.Fatalf("failed to unmarshal tx, got err %v", err)
+	}
+	if len(decoded.Msgs) != 1 {
+		t.Fatalf("diff number of msgs, got %v, want 1", len(decoded.Msgs))
+	}
+	msg, ok := decoded.Msgs[0].(customMsg)
+	if !ok {
+		t.Fatalf("diff msg type, got %T", decoded.Msgs[0])
+	}
+	if msg != (customMsg{Username: "user1", Value: 10}) {
+		t.Errorf("diff msg, got %v", msg)
+	}
+}
+
+func TestGetSignBytes(t *testing.T) {
+	transport := NewTransportFromArgs("test-chain", "")
+	msg := model.FollowMsg{Follower: "user1", Followee: "user2"}
+
+	signBytes, err := transport.GetSignBytes(msg, "lino-testnet", 5)
+	if err != nil {
+		t.Fatalf("failed to get sign bytes, got err %v", err)
+	}
+	expect := `{"account_number":"0","chain_id":"lino-testnet","fee":{"amount":[],"gas":"0"},"memo":"",` +
+		`"msgs":[{"type":"lino/follow","value":{"followee":"user2","follower":"user1"}}],"sequence":"5"}`
+	if string(signBytes) != expect {
+		t.Errorf("diff sign bytes, got %s, want %s", signBytes, expect)
+	}
+
+	encoded, err := EncodeSignMsg(transport.Cdc, []model.Msg{msg}, "lino-testnet", 5)
+	if err != nil {
+		t.Fatalf("failed to encode sign msg, got err %v", err)
+	}
+	if string(signBytes) != string(encoded) {
+		t.Errorf("diff from EncodeSignMsg, got %s, want %s", signBytes, encoded)
+	}
+}
+
+func TestSignBytesEncoding(t *testing.T) {
+	transport := NewTransportFromArgs("test-chain", "")
+	if err := transport.RegisterMsg("lino/custom", customMsg{}); err != nil {
+		t.Fatalf("failed to register msg, got err %v", err)
+	}
+	msg := customMsg{Username: "user1", Value: 9007199254740993}
+
+	testCases := map[string]struct {
+		encoding      SignBytesEncoding
+		expect        string
+		expectErrCode errors.CodeType
+	}{
+		"amino JSON": {
+			encoding: SignBytesAminoJSON,
+			expect: `{"account_number":"0","chain_id":"lino-testnet","fee":{"amount":[],"gas":"0"},"memo":"",` +
+				`"msgs":[{"type":"lino/custom","value":{"username":"user1","value":"9007199254740993"}}],"sequence":"5"}`,
+		},
+		"JSON": {
+			encoding: SignBytesJSON,
+			expect: `{"account_number":0,"chain_id":"lino-testnet","fee":{"amount":[],"gas":0},"memo":"",` +
+				`"msgs":[{"username":"user1","value":9007199254740993}],"sequence":5}`,
+		},
+		"unknown encoding": {
+			encoding:      SignBytesEncoding(-1),
+			expectErrCode: errors.CodeInvalidArg,
+		},
+	}
+
+	for testName, tc := range testCases {
+		transport.SignBytesEncoding = tc.encoding
+		signBytes, err := transport.GetSignBytes(msg, "lino-testnet", 5)
+		if tc.expectErrCode != errors.CodeOK {
+			if vErr, ok := err.(errors.Error); !ok || vErr.CodeType() != tc.expectErrCode {
+				t.Errorf("%s: diff err, got %v, want code %v", testName, err, tc.expectErrCode)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: failed to get sign bytes, got err %v", testName, err)
+			continue
+		}
+		if string(signBytes) != tc.expect {
+			t.Errorf("%s: diff sign bytes, got %s, want %s", testName, signBytes, tc.expect)
+		}
+	}
+}
+
+func TestBuildSignedTx(t *testing.T) {
+	transport := NewTransportFromArgs("test-chain", "")
+	msg := model.FollowMsg{Follower: "user1", Followee: "user2"}
+	privKey := secp256k1.GenPrivKey()
+	otherKey := secp256k1.GenPrivKey()
+
+	signBytes, err := transport.GetSignBytes(msg, "test-chain", 5)
+	if err != nil {
+		t.Fatalf("failed to get sign bytes, got err %v", err)
+	}
+	sig, err := privKey.Sign(signBytes)
+	if err != nil {
+		t.Fatalf("failed to sign, got err %v", err)
+	}
+
+	testCases := map[string]struct {
+		pubKey        crypto.PubKey
+		seq           int64
+		expectErrCode errors.CodeType
+	}{
+		"matching public key": {
+			pubKey: privKey.PubKey(),
+			seq:    5,
+		},
+		"other public key": {
+			pubKey:        otherKey.PubKey(),
+			seq:           5,
+			expectErrCode: errors.CodeWrongSigningKey,
+		},
+		"signature of another sequence": {
+			pubKey:        privKey.PubKey(),
+			seq:           6,
+			expectErrCode: errors.CodeWrongSigningKey,
+		},
+		"no public key": {
+			seq:           5,
+			expectErrCode: errors.CodeInvalidArg,
+		},
+	}
+
+	for testName, tc := range testCases {
+		txBytes, err := transport.BuildSignedTx(msg, tc.pubKey, sig, tc.seq, "memo")
+		if tc.expectErrCode != errors.CodeOK {
+			if vErr, ok := err.(errors.Error); !ok || vErr.CodeType() != tc.expectErrCode {
+				t.Errorf("%s: diff err, got %v, want code %v", testName, err, tc.expectErrCode)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: failed to build tx, got err %v", testName, err)
+			continue
+		}
+		expect, err := EncodeTx(transport.Cdc, []model.Msg{msg}, tc.pubKey, sig, tc.seq, "memo")
+		if err != nil {
+			t.Fatalf("%s: failed to encode tx, got err %v", testName, err)
+		}
+		if string(txBytes) != string(expect) {
+			t.Errorf("%s: diff tx, got %s, want %s", testName, txBytes, expect)
+		}
+	}
+}
+
+func TestBeforeBroadcast(t *testing.T) {
+	transport := NewTransportFromArgs("test-chain", "")
+	signer, err := NewSignerFromHex(hex.EncodeToString(secp256k1.GenPrivKey().Bytes()))
+	if err != nil {
+		t.Fatalf("failed to create signer, got err %v", err)
+	}
+	msg := model.FollowMsg{Follower: "user1", Followee: "user2"}
+
+	txBytes, err := transport.SignBuild(msg, signer, 1, "")
+	if err != nil {
+		t.Fatalf("failed to sign tx, got err %v", err)
+	}
+	expectHash := TxHash(txBytes)
+
+	var gotHash string
+	transport.BeforeBroadcast = func(txHash string) error {
+		gotHash = txHash
+		return fmt.Errorf("stop")
+	}
+	if _, err := transport.SignBuildBroadcastWithSigner(context.Background(), msg, signer, 1, "", true); err == nil {
+		t.Errorf("expect error when BeforeBroadcast fails")
+	}
+	if gotHash != expectHash {
+		t.Errorf("diff tx hash, got %v, want %v", gotHash, expectHash)
+	}
+}
+
+func TestTxHash(t *testing.T) {
+	transport := NewTransportFromArgs("test-chain", "")
+	signer, err := NewSignerFromHex(hex.EncodeToString(secp256k1.GenPrivKey().Bytes()))
+	if err != nil {
+		t.Fatalf("failed to create signer, got err %v", err)
+	}
+	txBytes, err := transport.SignBuild(model.FollowMsg{Follower: "user1", Followee: "user2"}, signer, 1, "")
+	if err != nil {
+		t.Fatalf("failed to sign tx, got err %v", err)
+	}
+
+	// the node returns the hash of the committed transaction in the commit result
+	resp, err := model.ParseBroadcastResult(&ctypes.ResultBroadcastTxCommit{
+		Hash:   cmn.HexBytes(tmtypes.Tx(txBytes).Hash()),
+		Height: 1,
+	})
+	if err != nil {
+		t.Fatalf("failed to parse commit result, got err %v", err)
+	}
+	if hash := TxHash(txBytes); hash != resp.CommitHash {
+		t.Errorf("diff tx hash, got %v, want %v", hash, resp.CommitHash)
+	}
+}
+
+func TestGetNodeNotConfigured(t *testing.T) {
+	_, err := Transport{}.GetNode()
+	vErr, ok := err.(errors.Error)
+	if !ok {
+		t.Fatalf("expect typed error, got %v", err)
+	}
+	if vErr.CodeType() != errors.CodeNodeNotConfigured {
+		t.Errorf("diff code type, got %v, want %v", vErr.CodeType(), errors.CodeNodeNotConfigured)
+	}
+}
+
+func TestProposalCodec(t *testing.T) {
+	cdc := MakeProposalCodec()
+	var proposal model.Proposal = &model.ChangeParamProposal{
+		ProposalInfo: model.ProposalInfo{Creator: "user1", ProposalID: "1", CreatedAt: 100},
+		Param:        model.ValidatorParam{ValidatorListSize: 21},
+		Reason:       "reason",
+	}
+	bz, err := cdc.MarshalJSON(proposal)
+	if err != nil {
+		t.Fatalf("failed to marshal proposal, got err %v", err)
+	}
+
+	decoded := new(model.Proposal)
+	if err := cdc.UnmarshalJSON(bz, decoded); err != nil {
+		t.Fatalf("failed to unmarshal proposal, got err %v", err)
+	}
+	changeParam, ok := (*decoded).(*model.ChangeParamProposal)
+	if !ok {
+		t.Fatalf("diff proposal type, got %T", *decoded)
+	}
+	if info := changeParam.GetProposalInfo(); info.ProposalID != "1" || info.CreatedAt != 100 {
+		t.Errorf("diff proposal info, got %v", info)
+	}
+	param, ok := changeParam.Param.(model.ValidatorParam)
+	if !ok || param.ValidatorListSize != 21 {
+		t.Errorf("diff param, got %v", changeParam.Param)
+	}
+}
+
+func TestCloseWithoutNode(t *testing.T) {
+	if err := (Transport{}).Close(); err != nil {
+		t.Errorf("failed to close transport without node, got err %v", err)
+	}
+}
+
+func TestDecodeJSONMsg(t *testing.T) {
+	transport := NewTransportFromArgs("test-chain", "")
+
+	testCases := map[string]struct {
+		msgType        string
+		json           string
+		expectMsg      model.Msg
+		expectCodeType errors.CodeType
+	}{
+		"transfer": {
+			msgType:   "lino/transfer",
+			json:      `{"sender":"user1","receiver":"user2","amount":"1","memo":"memo"}`,
+			expectMsg: model.TransferMsg{Sender: "user1", Receiver: "user2", Amount: "1", Memo: "memo"},
+		},
+		"unregistered type": {
+			msgType:        "lino/unknown",
+			json:           `{}`,
+			expectCodeType: errors.CodeInvalidArg,
+		},
+		"registered type which is not a msg": {
+			msgType:        "auth/StdTx",
+			json:           `{}`,
+			expectCodeType: errors.CodeInvalidArg,
+		},
+		"invalid json": {
+			msgType:        "lino/transfer",
+			json:           `{"sender":1}`,
+			expectCodeType: errors.CodeInvalidArg,
+		},
+	}
+
+	for testName, tc := range testCases {
+		msg, err := transport.DecodeJSONMsg(tc.msgType, []byte(tc.json))
+		if tc.expectCodeType == errors.CodeOK {
+			if err != nil {
+				t.Errorf("%s: failed to decode msg, got err %v", testName, err)
+			} else if msg != tc.expectMsg {
+				t.Errorf("%s: diff msg, got %v, want %v", testName, msg, tc.expectMsg)
+			}
+			continue
+		}
+		if vErr, ok := err.(errors.Error); !ok || vErr.CodeType() != tc.expectCodeType {
+			t.Errorf("%s: diff err, got %v, want code %v", testName, err, tc.expectCodeType)
+		}
+	}
+}
+
+// blockingQueryClient blocks ABCI queries until release is closed, other calls panic.
+type blockingQueryClient struct {
+	rpcclient.Client
+	release chan struct{}
+}
+
+func (c *blockingQueryClient) ABCIQueryWithOptions(path string, data cmn.HexBytes,
+	opts rpcclient.ABCIQueryOptions) (*ctypes.ResultABCIQuery, error) {
+	<-c.release
+	return &ctypes.ResultABCIQuery{}, nil
+}
+
+func TestQuerySubspaceCancel(t *testing.T) {
+	client := &blockingQueryClient{release: make(chan struct{})}
+	defer close(client.release)
+	transport := Transport{client: client}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		_, err := transport.QuerySubspace(ctx, []byte("k"), "account")
+		done <- err
+	}()
+	cancel()
+
+	select {
+	case err := <-done:
+		if vErr, ok := err.(errors.Error); !ok || vErr.CodeType() != errors.CodeTimeout {
+			t.Errorf("expect timeout error, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("QuerySubspace doesn't return after ctx is cancelled")
+	}
+}
+
+// inCacheClient rejects broadcasts as if the transaction is in the mempool, other calls panic.
+type inCacheClient struct {
+	rpcclient.Client
+}
+
+func (c inCacheClient) BroadcastTxAsync(tx tmtypes.Tx) (*ctypes.ResultBroadcastTx, error) {
+	return nil, fmt.Errorf("Error on broadcastTxAsync: %v", txInCacheErrMsg)
+}
+
+func (c inCacheClient) BroadcastTxSync(tx tmtypes.Tx) (*ctypes.ResultBroadcastTx, error) {
+	return nil, fmt.Errorf("Error on broadcastTxSync: %v", txInCacheErrMsg)
+}
+
+func (c inCacheClient) BroadcastTxCommit(tx tmtypes.Tx) (*ctypes.ResultBroadcastTxCommit, error) {
+	return nil, fmt.Errorf("Error on broadcastTxCommit: %v", txInCacheErrMsg)
+}
+
+func TestBroadcastTxInCache(t *testing.T) {
+	transport := Transport{client: inCacheClient{}}
+	tx := []byte("tx")
+
+	res, err := transport.BroadcastTx(context.Background(), tx, true)
+	if err != nil {
+		t.Fatalf("sync: expect success, got err %v", err)
+	}
+	resp, err := model.ParseBroadcastResult(res)
+	if err != nil {
+		t.Fatalf("sync: failed to parse result, got err %v", err)
+	}
+	if resp.CommitHash != TxHash(tx) {
+		t.Errorf("sync: diff hash, got %v, want %v", resp.CommitHash, TxHash(tx))
+	}
+
+	_, err = transport.BroadcastTx(context.Background(), tx, false)
+	vErr, ok := err.(errors.Error)
+	if !ok || vErr.CodeType() != errors.CodeTxAlreadyPending {
+		t.Fatalf("commit: expect TxAlreadyPending error, got %v", err)
+	}
+	if TxHash(vErr.RawData()) != TxHash(tx) {
+		t.Errorf("commit: diff tx in raw data, got %x, want %x", vErr.RawData(), tx)
+	}
+}
+
+func TestBroadcastTxWithMode(t *testing.T) {
+	transport := Transport{client: inCacheClient{}}
+	tx := []byte("tx")
+
+	testCases := map[string]struct {
+		mode      BroadcastMode
+		expectErr errors.CodeType
+	}{
+		"async": {
+			mode: BroadcastAsync,
+		},
+		"sync": {
+			mode: BroadcastSync,
+		},
+		"commit": {
+			mode:      BroadcastCommit,
+			expectErr: errors.CodeTxAlreadyPending,
+		},
+		"unknown mode": {
+			mode:      BroadcastMode(0),
+			expectErr: errors.CodeInvalidArg,
+		},
+	}
+
+	for testName, tc := range testCases {
+		res, err := transport.BroadcastTxWithMode(context.Background(), tx, tc.mode)
+		if tc.expectErr != errors.CodeOK {
+			if vErr, ok := err.(errors.Error); !ok || vErr.CodeType() != tc.expectErr {
+				t.Errorf("%s: diff err, got %v, want code %v", testName, err, tc.expectErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: expect success, got err %v", testName, err)
+			continue
+		}
+		if resp, err := model.ParseBroadcastResult(res); err != nil || resp.CommitHash != TxHash(tx) {
+			t.Errorf("%s: diff result, got %+v, err %v", testName, resp, err)
+		}
+	}
+}
+
+// statusClient reports the status of a node on network, other calls panic.
+type statusClient struct {
+	rpcclient.Client
+	network string
+}
+
+func (c statusClient) Status() (*ctypes.ResultStatus, error) {
+	status := &ctypes.ResultStatus{}
+	status.NodeInfo.Network = c.network
+	return status, nil
+}
+
+func TestCheckChainID(t *testing.T) {
+	testCases := map[string]struct {
+		network   string
+		expectErr bool
+	}{
+		"same chain": {
+			network: "test-chain",
+		},
+		"other chain": {
+			network:   "other-chain",
+			expectErr: true,
+		},
+	}
+
+	for testName, tc := range testCases {
+		transport := Transport{chainId: "test-chain", client: statusClient{network: tc.network}}
+		err := transport.CheckChainID(context.Background())
+		if !tc.expectErr {
+			if err != nil {
+				t.Errorf("%s: expect success, got err %v", testName, err)
+			}
+			continue
+		}
+		if vErr, ok := err.(errors.Error); !ok || vErr.CodeType() != errors.CodeChainIDMismatch {
+			t.Errorf("%s: diff err, got %v, want ChainIDMismatch", testName, err)
+			continue
+		}
+		if !strings.Contains(err.Error(), "test-chain") || !strings.Contains(err.Error(), tc.network) {
+			t.Errorf("%s: err %v doesn't name both chain IDs", testName, err)
+		}
+	}
+}
+
+func TestBroadcastTxRateLimitCancel(t *testing.T) {
+	transport := Transport{client: inCacheClient{}, RateLimiter: NewRateLimiter(0, 1)}
+	// use up the only token, the limiter never refills
+	if err := transport.RateLimiter.Wait(context.Background()); err != nil {
+		t.Fatalf("failed to take token, got err %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := transport.BroadcastTx(ctx, []byte("tx"), true)
+	if vErr, ok := err.(errors.Error); !ok || vErr.CodeType() != errors.CodeTimeout {
+		t.Errorf("expect timeout error, got %v", err)
+	}
+}
+
+func TestMaxTxBytes(t *testing.T) {
+	transport := NewTransportFromArgs("test-chain", "")
+	signer, err := NewSignerFromHex(hex.EncodeToString(secp256k1.GenPrivKey().Bytes()))
+	if err != nil {
+		t.Fatalf("failed to create signer, got err %v", err)
+	}
+	msg := model.FollowMsg{Follower: "user1", Followee: "user2"}
+	txBytes, err := transport.SignBuild(msg, signer, 1, "memo")
+	if err != nil {
+		t.Fatalf("failed to sign tx, got err %v", err)
+	}
+	size, err := transport.EstimateTxSize(msg, signer.PubKey(), 1, "memo")
+	if err != nil {
+		t.Fatalf("failed to estimate tx size, got err %v", err)
+	}
+	if size < len(txBytes) {
+		t.Errorf("estimated size %v is below the size %v of the signed tx", size, len(txBytes))
+	}
+
+	testCases := map[string]struct {
+		maxTxBytes    int
+		expectErrCode errors.CodeType
+	}{
+		"tx too large": {
+			maxTxBytes:    len(txBytes) - 1,
+			expectErrCode: errors.CodeTxTooLarge,
+		},
+		// the node is not configured, so a tx within the limit fails on the node
+		"tx within the limit": {
+			maxTxBytes:    len(txBytes),
+			expectErrCode: errors.CodeNodeNotConfigured,
+		},
+		"no limit": {
+			maxTxBytes:    0,
+			expectErrCode: errors.CodeNodeNotConfigured,
+		},
+	}
+
+	for testName, tc := range testCases {
+		_, err := Transport{MaxTxBytes: tc.maxTxBytes}.BroadcastTx(context.Background(), txBytes, true)
+		if vErr, ok := err.(errors.Error); !ok || vErr.CodeType() != tc.expectErrCode {
+			t.Errorf("%s: diff err, got %v, want code %v", testName, err, tc.expectErrCode)
+		}
+	}
+}
@@ -1,8 +1,10 @@
 package transport
 
 import (
+	"bytes"
 	"encoding/hex"
 	"encoding/json"
+	"strings"
 
 	"github.com/cosmos/cosmos-sdk/wire"
 	"github.com/lino-network/lino-go/errors"
@@ -10,6 +12,7 @@ import (
 
 	crypto "github.com/tendermint/tendermint/crypto"
 	cryptoAmino "github.com/tendermint/tendermint/crypto/encoding/amino"
+	tmtypes "github.com/tendermint/tendermint/types"
 )
 
 // ZeroFee is used in building a standard transaction.
@@ -81,25 +84,6 @@ func MakeCodec() *wire.Codec {
 	cdc.RegisterConcrete(model.ChangeAccountParamMsg{}, "lino/changeAccountParam", nil)
 	cdc.RegisterConcrete(model.ChangePostParamMsg{}, "lino/changePostParam", nil)
 
-	// // TODO:
-	// cdc.RegisterInterface((*model.Proposal)(nil), nil)
-	// cdc.RegisterConcrete(&model.ChangeParamProposal{}, "changeParam", nil)
-	// cdc.RegisterConcrete(&model.ProtocolUpgradeProposal{}, "upgrade", nil)
-	// cdc.RegisterConcrete(&model.ContentCensorshipProposal{}, "censorship", nil)
-
-	// cdc.RegisterInterface((*param.Parameter)(nil), nil)
-	// cdc.RegisterConcrete(param.EvaluateOfContentValueParam{}, "param/contentValue", nil)
-	// cdc.RegisterConcrete(param.GlobalAllocationParam{}, "param/allocation", nil)
-	// cdc.RegisterConcrete(param.InfraInternalAllocationParam{}, "param/infaAllocation", nil)
-	// cdc.RegisterConcrete(param.VoteParam{}, "param/vote", nil)
-	// cdc.RegisterConcrete(param.ProposalParam{}, "param/proposal", nil)
-	// cdc.RegisterConcrete(param.DeveloperParam{}, "param/developer", nil)
-	// cdc.RegisterConcrete(param.ValidatorParam{}, "param/validator", nil)
-	// cdc.RegisterConcrete(param.CoinDayParam{}, "param/coinDay", nil)
-	// cdc.RegisterConcrete(param.BandwidthParam{}, "param/bandwidth", nil)
-	// cdc.RegisterConcrete(param.AccountParam{}, "param/account", nil)
-	// cdc.RegisterConcrete(param.PostParam{}, "param/post", nil)
-
 	wire.RegisterCrypto(cdc)
 	return cdc
 }
@@ -117,21 +101,83 @@ func sortJSON(toSortJSON []byte) ([]byte, error) {
 	return js, nil
 }
 
+// MakeProposalCodec returns the codec of proposals, which are stored with
+// their concrete type and the concrete type of the changed parameter.
+// Parameters queried on their own are stored without type, so they are not
+// registered in the codec returned by MakeCodec.
+func MakeProposalCodec() *wire.Codec {
+	cdc := wire.NewCodec()
+
+	cdc.RegisterInterface((*model.Proposal)(nil), nil)
+	cdc.RegisterConcrete(&model.ChangeParamProposal{}, "changeParam", nil)
+	cdc.RegisterConcrete(&model.ProtocolUpgradeProposal{}, "upgrade", nil)
+	cdc.RegisterConcrete(&model.ContentCensorshipProposal{}, "censorship", nil)
+
+	cdc.RegisterInterface((*model.Parameter)(nil), nil)
+	cdc.RegisterConcrete(model.EvaluateOfContentValueParam{}, "param/contentValue", nil)
+	cdc.RegisterConcrete(model.GlobalAllocationParam{}, "param/allocation", nil)
+	cdc.RegisterConcrete(model.InfraInternalAllocationParam{}, "param/infaAllocation", nil)
+	cdc.RegisterConcrete(model.VoteParam{}, "param/vote", nil)
+	cdc.RegisterConcrete(model.ProposalParam{}, "param/proposal", nil)
+	cdc.RegisterConcrete(model.DeveloperParam{}, "param/developer", nil)
+	cdc.RegisterConcrete(model.ValidatorParam{}, "param/validator", nil)
+	cdc.RegisterConcrete(model.CoinDayParam{}, "param/coinDay", nil)
+	cdc.RegisterConcrete(model.BandwidthParam{}, "param/bandwidth", nil)
+	cdc.RegisterConcrete(model.AccountParam{}, "param/account", nil)
+	cdc.RegisterConcrete(model.PostParam{}, "param/post", nil)
+
+	wire.RegisterCrypto(cdc)
+	return cdc
+}
+
+// SignBytesEncoding selects how the sign bytes are encoded. It must match the
+// encoding the chain verifies signatures against, a mismatch fails every signature.
+type SignBytesEncoding int
+
+const (
+	// SignBytesAminoJSON is the amino JSON of model.SignMsg with sorted keys, where
+	// each message is {"type":"<registered name>","value":{...}} and int64 values
+	// are strings. It is the encoding of Lino blockchain and the default.
+	SignBytesAminoJSON SignBytesEncoding = iota
+	// SignBytesJSON is the standard JSON of model.SignMsg with sorted keys, where
+	// each message is encoded without its type and int64 values are numbers, as on
+	// chains built on cosmos-sdk versions before amino JSON sign bytes.
+	SignBytesJSON
+)
+
 // EncodeSignMsg encodes the message to the standard signed message.
 func EncodeSignMsg(cdc *wire.Codec, msgs []model.Msg, chainId string, seq int64) ([]byte, error) {
-	feeBytes, err := cdc.MarshalJSON(ZeroFee)
+	return EncodeSignMsgWithEncoding(cdc, msgs, chainId, seq, SignBytesAminoJSON)
+}
+
+// EncodeSignMsgWithEncoding encodes the message to the standard signed message
+// in encoding, see SignBytesEncoding.
+func EncodeSignMsgWithEncoding(cdc *wire.Codec, msgs []model.Msg, chainId string, seq int64,
+	encoding SignBytesEncoding) ([]byte, error) {
+	var marshal func(o interface{}) ([]byte, error)
+	var sortKeys func(bz []byte) ([]byte, error)
+	switch encoding {
+	case SignBytesAminoJSON:
+		marshal, sortKeys = cdc.MarshalJSON, sortJSON
+	case SignBytesJSON:
+		marshal, sortKeys = json.Marshal, sortJSONKeepNumbers
+	default:
+		return nil, errors.InvalidArgf("unknown sign bytes encoding %v", encoding)
+	}
+
+	feeBytes, err := marshal(ZeroFee)
 	if err != nil {
 		return nil, err
 	}
 
 	var msgsBytes []json.RawMessage
 	for _, msg := range msgs {
-		bz, err := cdc.MarshalJSON(msg)
+		bz, err := marshal(msg)
 		if err != nil {
 			return nil, err
 		}
 
-		signBytes, err := sortJSON(bz)
+		signBytes, err := sortKeys(bz)
 		if err != nil {
 			return nil, err
 		}
@@ -148,12 +194,12 @@ func EncodeSignMsg(cdc *wire.Codec, msgs []model.Msg, chainId string, seq int64)
 		Sequence:      seq,
 	}
 
-	signMsgBytes, err := cdc.MarshalJSON(stdSignMsg)
+	signMsgBytes, err := marshal(stdSignMsg)
 	if err != nil {
 		return nil, err
 	}
 
-	return sortJSON(signMsgBytes)
+	return sortKeys(signMsgBytes)
 }
 
 // EncodeTx encodes a message to the standard transaction.
@@ -174,9 +220,15 @@ func EncodeTx(cdc *wire.Codec, msgs []model.Msg, pubKey crypto.PubKey,
 	return cdc.MarshalJSON(stdTx)
 }
 
+// TxHash returns the hash the blockchain assigns to the signed transaction
+// txBytes, as uppercase hex in the same format as BroadcastResponse.CommitHash.
+func TxHash(txBytes []byte) string {
+	return strings.ToUpper(hex.EncodeToString(tmtypes.Tx(txBytes).Hash()))
+}
+
 // GetPrivKeyFromHex gets private key from private key hex.
 func GetPrivKeyFromHex(privHex string) (crypto.PrivKey, error) {
-	keyBytes, err := hex.DecodeString(privHex)
+	keyBytes, err := decodeHex(privHex)
 	if err != nil {
 		return nil, err
 	}
@@ -185,7 +237,7 @@ func GetPrivKeyFromHex(privHex string) (crypto.PrivKey, error) {
 
 // GetPubKeyFromHex gets public key from public key hex.
 func GetPubKeyFromHex(pubHex string) (crypto.PubKey, error) {
-	keyBytes, err := hex.DecodeString(pubHex)
+	keyBytes, err := decodeHex(pubHex)
 	if err != nil {
 		return nil, err
 	}
@@ -195,3 +247,56 @@ func GetPubKeyFromHex(pubHex string) (crypto.PubKey, error) {
 	}
 	return cryptoAmino.PubKeyFromBytes(keyBytes)
 }
+
+// VerifySignature returns true if sigHex is the hex of a signature of msg
+// by the private key of pubKeyHex, e.g. a challenge signed to prove the
+// ownership of a key. An error is returned if the key or signature can't be parsed.
+func VerifySignature(pubKeyHex string, msg []byte, sigHex string) (bool, error) {
+	pubKey, err := GetPubKeyFromHex(pubKeyHex)
+	if err != nil {
+		return false, err
+	}
+	sig, err := decodeHex(sigHex)
+	if err != nil {
+		return false, err
+	}
+	return pubKey.VerifyBytes(msg, sig), nil
+}
+
+// normalizeHex strips an optional "0x" prefix and surrounding spaces of hexStr
+// and lowercases it. An InvalidHex error is returned if the rest is not an even
+// number of hex digits.
+func normalizeHex(hexStr string) (string, error) {
+	normalized := strings.ToLower(strings.TrimSpace(hexStr))
+	normalized = strings.TrimPrefix(normalized, "0x")
+	if len(normalized)%2 != 0 {
+		return "", errors.InvalidHexf("hex %v has an odd length", hexStr)
+	}
+	for _, c := range normalized {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return "", errors.InvalidHexf("hex %v has a non hex character %q", hexStr, c)
+		}
+	}
+	return normalized, nil
+}
+
+// decodeHex decodes hexStr after normalizeHex.
+func decodeHex(hexStr string) ([]byte, error) {
+	normalized, err := normalizeHex(hexStr)
+	if err != nil {
+		return nil, err
+	}
+	return hex.DecodeString(normalized)
+}
+
+// sortJSONKeepNumbers is sortJSON which keeps the literal of numbers,
+// so that int64 values beyond the precision of float64 are not rounded.
+func sortJSONKeepNumbers(toSortJSON []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(toSortJSON))
+	decoder.UseNumber()
+	var c interface{}
+	if err := decoder.Decode(&c); err != nil {
+		return nil, err
+	}
+	return json.Marshal(c)
+}
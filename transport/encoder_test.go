@@ -0,0 +1,136 @@
+package transport
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/lino-network/lino-go/errors"
+
+	"github.com/tendermint/tendermint/crypto/secp256k1"
+)
+
+func TestVerifySignature(t *testing.T) {
+	privKey := secp256k1.GenPrivKey()
+	pubKeyHex := hex.EncodeToString(privKey.PubKey().Bytes())
+	otherPubKeyHex := hex.EncodeToString(secp256k1.GenPrivKey().PubKey().Bytes())
+	msg := []byte("challenge")
+	sig, err := privKey.Sign(msg)
+	if err != nil {
+		t.Fatalf("failed to sign msg, got err %v", err)
+	}
+	sigHex := hex.EncodeToString(sig)
+
+	testCases := map[string]struct {
+		pubKeyHex    string
+		msg          []byte
+		sigHex       string
+		expectResult bool
+		expectErr    bool
+	}{
+		"valid signature": {
+			pubKeyHex:    pubKeyHex,
+			msg:          msg,
+			sigHex:       sigHex,
+			expectResult: true,
+		},
+		"different msg": {
+			pubKeyHex: pubKeyHex,
+			msg:       []byte("other challenge"),
+			sigHex:    sigHex,
+		},
+		"different key": {
+			pubKeyHex: otherPubKeyHex,
+			msg:       msg,
+			sigHex:    sigHex,
+		},
+		"invalid signature hex": {
+			pubKeyHex: pubKeyHex,
+			msg:       msg,
+			sigHex:    "xyz",
+			expectErr: true,
+		},
+		"invalid public key hex": {
+			pubKeyHex: "xyz",
+			msg:       msg,
+			sigHex:    sigHex,
+			expectErr: true,
+		},
+	}
+
+	for testName, tc := range testCases {
+		got, err := VerifySignature(tc.pubKeyHex, tc.msg, tc.sigHex)
+		if tc.expectErr {
+			if err == nil {
+				t.Errorf("%s: expect error", testName)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: failed to verify signature, got err %v", testName, err)
+			continue
+		}
+		if got != tc.expectResult {
+			t.Errorf("%s: diff result, got %v, want %v", testName, got, tc.expectResult)
+		}
+	}
+}
+
+func TestNormalizeHex(t *testing.T) {
+	testCases := map[string]struct {
+		input          string
+		expectHex      string
+		expectCodeType errors.CodeType
+	}{
+		"lower case": {
+			input:     "0aff",
+			expectHex: "0aff",
+		},
+		"upper case with prefix": {
+			input:     "0x0AFF",
+			expectHex: "0aff",
+		},
+		"upper case prefix with spaces": {
+			input:     " 0X0aFf\n",
+			expectHex: "0aff",
+		},
+		"empty": {
+			input:     "",
+			expectHex: "",
+		},
+		"odd length": {
+			input:          "0x0af",
+			expectCodeType: errors.CodeInvalidHex,
+		},
+		"non hex character": {
+			input:          "0agg",
+			expectCodeType: errors.CodeInvalidHex,
+		},
+	}
+
+	for testName, tc := range testCases {
+		got, err := normalizeHex(tc.input)
+		if tc.expectCodeType == errors.CodeOK {
+			if err != nil {
+				t.Errorf("%s: failed to normalize hex, got err %v", testName, err)
+			} else if got != tc.expectHex {
+				t.Errorf("%s: diff hex, got %v, want %v", testName, got, tc.expectHex)
+			}
+			continue
+		}
+		if vErr, ok := err.(errors.Error); !ok || vErr.CodeType() != tc.expectCodeType {
+			t.Errorf("%s: diff err, got %v, want code %v", testName, err, tc.expectCodeType)
+		}
+	}
+
+	// keys copied with a prefix and in upper case are accepted
+	privKey := secp256k1.GenPrivKey()
+	pubKeyHex := "0x" + strings.ToUpper(hex.EncodeToString(privKey.PubKey().Bytes()))
+	pubKey, err := GetPubKeyFromHex(pubKeyHex)
+	if err != nil {
+		t.Fatalf("failed to get pub key from %v, got err %v", pubKeyHex, err)
+	}
+	if !pubKey.Equals(privKey.PubKey()) {
+		t.Errorf("diff pub key, got %v, want %v", pubKey, privKey.PubKey())
+	}
+}
@@ -0,0 +1,77 @@
+package transport
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/lino-network/lino-go/errors"
+)
+
+// RateLimiter is a token bucket which allows rate calls per second on average
+// and bursts of up to burst calls. It is safe for concurrent use.
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter returns a RateLimiter with a full bucket. A burst below 1 is treated as 1.
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Wait blocks until a call is allowed. It returns a Timeout error if ctx is
+// done first, in which case the call doesn't use up a token.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	delay := l.reserve()
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return errors.Timeout("wait for rate limit timeout").AddCause(ctx.Err())
+	}
+}
+
+// reserve takes a token from the bucket, which may go negative,
+// and returns how long the caller has to wait for it.
+func (l *RateLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	if l.rate <= 0 {
+		// no refill, only ctx can end the wait
+		return time.Duration(1<<63 - 1)
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
@@ -0,0 +1,44 @@
+package transport
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lino-network/lino-go/errors"
+)
+
+func TestRateLimiterWait(t *testing.T) {
+	limiter := NewRateLimiter(50, 2)
+
+	// the burst is allowed right away
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		if err := limiter.Wait(context.Background()); err != nil {
+			t.Fatalf("burst call %v: got err %v", i, err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Millisecond {
+		t.Errorf("burst calls: waited %v, want no wait", elapsed)
+	}
+
+	// the next call waits for a token, 20ms at 50 calls per second
+	if err := limiter.Wait(context.Background()); err != nil {
+		t.Fatalf("throttled call: got err %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 10*time.Millisecond {
+		t.Errorf("throttled call: waited %v, want about 20ms", elapsed)
+	}
+
+	// a done ctx ends the wait with a Timeout error
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	noRefill := NewRateLimiter(0, 1)
+	if err := noRefill.Wait(ctx); err != nil {
+		t.Fatalf("first call without refill: got err %v", err)
+	}
+	err := noRefill.Wait(ctx)
+	if vErr, ok := err.(errors.Error); !ok || vErr.CodeType() != errors.CodeTimeout {
+		t.Errorf("canceled call: got err %v, want timeout", err)
+	}
+}
@@ -0,0 +1,101 @@
+package transport
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+
+	"github.com/lino-network/lino-go/errors"
+)
+
+// SignedTxVersion is the version of the SignedTx envelope written by MarshalSignedTx.
+const SignedTxVersion = 1
+
+// SignedTx is a portable JSON envelope of a signed transaction, e.g. to move a
+// transaction signed on an offline machine to one that broadcasts it:
+//
+//	{"version":1,"chain_id":"<chainId>","sequence":<seq>,"tx_hash":"<TxHash>","tx":"<hex of tx bytes>"}
+type SignedTx struct {
+	Version int `json:"version"`
+	// ChainID is the chain the transaction is signed for.
+	ChainID string `json:"chain_id"`
+	// Sequence is the sequence number the transaction is signed with.
+	Sequence int64 `json:"sequence"`
+	// TxHash is the hash of the transaction, see TxHash.
+	TxHash string `json:"tx_hash"`
+	// Tx is the hex of the transaction bytes, e.g. from SignBuild.
+	Tx string `json:"tx"`
+}
+
+// MarshalSignedTx wraps txBytes, signed for chainID with sequence number seq,
+// into a SignedTx envelope.
+func MarshalSignedTx(txBytes []byte, chainID string, seq int64) ([]byte, error) {
+	signedTx := SignedTx{
+		Version:  SignedTxVersion,
+		ChainID:  chainID,
+		Sequence: seq,
+		TxHash:   TxHash(txBytes),
+		Tx:       strings.ToUpper(hex.EncodeToString(txBytes)),
+	}
+	if err := signedTx.validate(); err != nil {
+		return nil, err
+	}
+	return json.Marshal(signedTx)
+}
+
+// UnmarshalSignedTx decodes a SignedTx envelope and returns it with the
+// transaction bytes ready to be broadcast. The envelope is rejected with an
+// InvalidArg error if its version is unknown, its chain ID is empty, or its
+// hash or sequence number doesn't match the transaction.
+func UnmarshalSignedTx(data []byte) (*SignedTx, []byte, error) {
+	signedTx := new(SignedTx)
+	if err := json.Unmarshal(data, signedTx); err != nil {
+		return nil, nil, errors.DecodeFailedf("failed to decode signed tx").AddCause(err).AddRawData(data)
+	}
+	if err := signedTx.validate(); err != nil {
+		return nil, nil, err
+	}
+	txBytes, _ := decodeHex(signedTx.Tx)
+	return signedTx, txBytes, nil
+}
+
+// validate checks the envelope against the transaction it carries.
+func (signedTx SignedTx) validate() error {
+	if signedTx.Version != SignedTxVersion {
+		return errors.InvalidArgf("unknown signed tx version %v", signedTx.Version)
+	}
+	if signedTx.ChainID == "" {
+		return errors.InvalidArgf("signed tx has no chain ID")
+	}
+	txBytes, err := decodeHex(signedTx.Tx)
+	if err != nil {
+		return errors.InvalidArgf("invalid tx hex").AddCause(err)
+	}
+	if len(txBytes) == 0 {
+		return errors.InvalidArgf("signed tx has no tx")
+	}
+	if hash := TxHash(txBytes); !strings.EqualFold(signedTx.TxHash, hash) {
+		return errors.InvalidArgf("tx hash %v doesn't match the tx, want %v", signedTx.TxHash, hash)
+	}
+
+	// only the signatures are read, so messages registered by the app are not needed
+	var tx struct {
+		Value struct {
+			Signatures []struct {
+				Sequence int64 `json:"sequence,string"`
+			} `json:"signatures"`
+		} `json:"value"`
+	}
+	if err := json.Unmarshal(txBytes, &tx); err != nil {
+		return errors.InvalidArgf("failed to decode the tx").AddCause(err)
+	}
+	if len(tx.Value.Signatures) == 0 {
+		return errors.InvalidArgf("tx is not signed")
+	}
+	for _, sig := range tx.Value.Signatures {
+		if sig.Sequence != signedTx.Sequence {
+			return errors.InvalidArgf("tx is signed with sequence %v, want %v", sig.Sequence, signedTx.Sequence)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package transport
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/lino-network/lino-go/errors"
+	"github.com/lino-network/lino-go/model"
+
+	"github.com/tendermint/tendermint/crypto/secp256k1"
+)
+
+func TestSignedTx(t *testing.T) {
+	transport := NewTransportFromArgs("test-chain", "")
+	signer, err := NewSignerFromHex(hex.EncodeToString(secp256k1.GenPrivKey().Bytes()))
+	if err != nil {
+		t.Fatalf("failed to create signer, got err %v", err)
+	}
+	txBytes, err := transport.SignBuild(model.FollowMsg{Follower: "user1", Followee: "user2"}, signer, 5, "")
+	if err != nil {
+		t.Fatalf("failed to sign tx, got err %v", err)
+	}
+	envelope, err := MarshalSignedTx(txBytes, "test-chain", 5)
+	if err != nil {
+		t.Fatalf("failed to marshal signed tx, got err %v", err)
+	}
+
+	modify := func(f func(signedTx *SignedTx)) []byte {
+		signedTx := SignedTx{}
+		if err := json.Unmarshal(envelope, &signedTx); err != nil {
+			t.Fatalf("failed to decode envelope, got err %v", err)
+		}
+		f(&signedTx)
+		bz, _ := json.Marshal(signedTx)
+		return bz
+	}
+
+	testCases := map[string]struct {
+		data      []byte
+		expectErr errors.CodeType
+	}{
+		"round trip": {
+			data: envelope,
+		},
+		"lowercase hex": {
+			data: modify(func(signedTx *SignedTx) {
+				signedTx.Tx = hex.EncodeToString(txBytes)
+			}),
+		},
+		"unknown version": {
+			data:      modify(func(signedTx *SignedTx) { signedTx.Version = 2 }),
+			expectErr: errors.CodeInvalidArg,
+		},
+		"no chain ID": {
+			data:      modify(func(signedTx *SignedTx) { signedTx.ChainID = "" }),
+			expectErr: errors.CodeInvalidArg,
+		},
+		"wrong sequence": {
+			data:      modify(func(signedTx *SignedTx) { signedTx.Sequence = 6 }),
+			expectErr: errors.CodeInvalidArg,
+		},
+		"wrong hash": {
+			data:      modify(func(signedTx *SignedTx) { signedTx.TxHash = TxHash([]byte("other")) }),
+			expectErr: errors.CodeInvalidArg,
+		},
+		"invalid hex": {
+			data:      modify(func(signedTx *SignedTx) { signedTx.Tx = "xyz" }),
+			expectErr: errors.CodeInvalidArg,
+		},
+		"not an envelope": {
+			data:      []byte("not json"),
+			expectErr: errors.CodeDecodeFailed,
+		},
+	}
+
+	for testName, tc := range testCases {
+		signedTx, gotTxBytes, err := UnmarshalSignedTx(tc.data)
+		if tc.expectErr != errors.CodeOK {
+			if vErr, ok := err.(errors.Error); !ok || vErr.CodeType() != tc.expectErr {
+				t.Errorf("%s: diff err, got %v, want code %v", testName, err, tc.expectErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: failed to unmarshal signed tx, got err %v", testName, err)
+			continue
+		}
+		if !bytes.Equal(gotTxBytes, txBytes) {
+			t.Errorf("%s: diff tx bytes", testName)
+		}
+		if signedTx.ChainID != "test-chain" || signedTx.Sequence != 5 {
+			t.Errorf("%s: diff envelope, got %+v", testName, signedTx)
+		}
+	}
+}
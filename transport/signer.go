@@ -0,0 +1,19 @@
+package transport
+
+import (
+	"github.com/tendermint/tendermint/crypto"
+)
+
+// Signer signs transactions on behalf of an account. Implementations may keep
+// the private key outside of the process, e.g. in a Ledger or an HSM.
+type Signer interface {
+	// Sign returns the signature of the sign bytes produced by EncodeSignMsg.
+	Sign(signBytes []byte) ([]byte, error)
+	// PubKey returns the public key which verifies the signatures.
+	PubKey() crypto.PubKey
+}
+
+// NewSignerFromHex returns an in-memory Signer holding the private key given in hex.
+func NewSignerFromHex(privKeyHex string) (Signer, error) {
+	return GetPrivKeyFromHex(privKeyHex)
+}
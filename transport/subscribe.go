@@ -0,0 +1,166 @@
+package transport
+
+import (
+	"context"
+	"time"
+
+	"github.com/lino-network/lino-go/errors"
+
+	cmn "github.com/tendermint/tendermint/libs/common"
+	tmpubsub "github.com/tendermint/tendermint/libs/pubsub"
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+)
+
+const (
+	// subscriptionBufferSize is the number of events buffered for a slow subscriber.
+	subscriptionBufferSize = 100
+
+	// healthCheckInterval is how often SubscribeWithReconnect checks that the node still responds.
+	healthCheckInterval = 10 * time.Second
+	// bounds of the exponential backoff between reconnection attempts
+	minReconnectInterval = time.Second
+	maxReconnectInterval = time.Minute
+)
+
+// newWSClient returns a client with a websocket of its own to nodeUrl.
+var newWSClient = func(nodeUrl string) rpcclient.Client {
+	return rpcclient.NewHTTP(nodeUrl, "/websocket")
+}
+
+// Subscribe subscribes to the events matching query, e.g. tmtypes.EventQueryNewBlock,
+// over the websocket of the node, which is started on first use and stopped by Close.
+// subscriber must be unique among the subscriptions of the transport. Events are
+// delivered until ctx is done, then the subscription is cancelled and the channel closed.
+func (t Transport) Subscribe(ctx context.Context, subscriber string, query tmpubsub.Query) (<-chan interface{}, error) {
+	node, err := t.GetNode()
+	if err != nil {
+		return nil, err
+	}
+	if !node.IsRunning() {
+		if err := node.Start(); err != nil && err != cmn.ErrAlreadyStarted {
+			return nil, errors.QueryFailf("Subscribe: failed to start websocket").AddCause(err)
+		}
+	}
+
+	out := make(chan interface{}, subscriptionBufferSize)
+	if err := node.Subscribe(ctx, subscriber, query, out); err != nil {
+		return nil, errors.QueryFailf("Subscribe: failed to subscribe to %v", query).AddCause(err)
+	}
+
+	events := make(chan interface{}, subscriptionBufferSize)
+	go func() {
+		defer close(events)
+		defer node.Unsubscribe(context.Background(), subscriber, query)
+		for {
+			select {
+			case event := <-out:
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events, nil
+}
+
+// SubscribeWithReconnect is Subscribe over a websocket of its own, which is
+// re-established with exponential backoff, from minReconnectInterval to
+// maxReconnectInterval, once the node stops responding, e.g. on a node restart.
+// Events emitted while disconnected are lost, so after every reconnection the cause
+// of the disconnection is sent on the second channel, which holds at most one pending
+// signal. Both channels are closed only once ctx is done.
+func (t Transport) SubscribeWithReconnect(ctx context.Context, subscriber string,
+	query tmpubsub.Query) (<-chan interface{}, <-chan error, error) {
+	if t.nodeUrl == "" {
+		return nil, nil, errors.NodeNotConfigured("Must define node URL")
+	}
+	client, out, err := connectSubscription(ctx, t.nodeUrl, subscriber, query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	events := make(chan interface{}, subscriptionBufferSize)
+	gaps := make(chan error, 1)
+	go func() {
+		defer close(events)
+		defer close(gaps)
+		for {
+			cause := forwardEvents(ctx, client, out, events)
+			client.Stop()
+			if ctx.Err() != nil {
+				return
+			}
+
+			if client, out = reconnectSubscription(ctx, t.nodeUrl, subscriber, query); client == nil {
+				return
+			}
+			select {
+			case gaps <- cause:
+			default:
+			}
+		}
+	}()
+	return events, gaps, nil
+}
+
+func connectSubscription(ctx context.Context, nodeUrl, subscriber string,
+	query tmpubsub.Query) (rpcclient.Client, <-chan interface{}, error) {
+	client := newWSClient(nodeUrl)
+	if err := client.Start(); err != nil {
+		return nil, nil, errors.QueryFailf("Subscribe: failed to start websocket").AddCause(err)
+	}
+	out := make(chan interface{}, subscriptionBufferSize)
+	if err := client.Subscribe(ctx, subscriber, query, out); err != nil {
+		client.Stop()
+		return nil, nil, errors.QueryFailf("Subscribe: failed to subscribe to %v", query).AddCause(err)
+	}
+	return client, out, nil
+}
+
+// reconnectSubscription retries connectSubscription with exponential backoff,
+// it returns a nil client if ctx is done first.
+func reconnectSubscription(ctx context.Context, nodeUrl, subscriber string,
+	query tmpubsub.Query) (rpcclient.Client, <-chan interface{}) {
+	backoff := minReconnectInterval
+	for {
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return nil, nil
+		}
+		if client, out, err := connectSubscription(ctx, nodeUrl, subscriber, query); err == nil {
+			return client, out
+		}
+		if backoff *= 2; backoff > maxReconnectInterval {
+			backoff = maxReconnectInterval
+		}
+	}
+}
+
+// forwardEvents forwards events from out until ctx is done, when it returns nil,
+// or until the node stops responding, when it returns the health check error.
+func forwardEvents(ctx context.Context, client rpcclient.Client,
+	out <-chan interface{}, events chan<- interface{}) error {
+	ticker := time.NewTicker(healthCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case event := <-out:
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return nil
+			}
+		case <-ticker.C:
+			if _, err := client.Health(); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
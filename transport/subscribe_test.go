@@ -0,0 +1,68 @@
+package transport
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lino-network/lino-go/errors"
+
+	tmpubsub "github.com/tendermint/tendermint/libs/pubsub"
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	tmtypes "github.com/tendermint/tendermint/types"
+)
+
+// fakeWSClient sends events to the first subscription, other calls panic.
+type fakeWSClient struct {
+	rpcclient.Client
+	events  []interface{}
+	stopped chan struct{}
+}
+
+func (c *fakeWSClient) Start() error { return nil }
+
+func (c *fakeWSClient) Stop() error {
+	close(c.stopped)
+	return nil
+}
+
+func (c *fakeWSClient) Health() (*ctypes.ResultHealth, error) { return &ctypes.ResultHealth{}, nil }
+
+func (c *fakeWSClient) Subscribe(ctx context.Context, subscriber string,
+	query tmpubsub.Query, out chan<- interface{}) error {
+	for _, event := range c.events {
+		out <- event
+	}
+	return nil
+}
+
+func TestSubscribeWithReconnect(t *testing.T) {
+	client := &fakeWSClient{events: []interface{}{"block1", "block2"}, stopped: make(chan struct{})}
+	defer func(origin func(string) rpcclient.Client) { newWSClient = origin }(newWSClient)
+	newWSClient = func(nodeUrl string) rpcclient.Client { return client }
+
+	if _, _, err := (Transport{}).SubscribeWithReconnect(context.Background(), "test", tmtypes.EventQueryNewBlock); err == nil {
+		t.Errorf("subscribe without node: expect error")
+	} else if vErr, ok := err.(errors.Error); !ok || vErr.CodeType() != errors.CodeNodeNotConfigured {
+		t.Errorf("subscribe without node: diff err, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, gaps, err := (Transport{nodeUrl: "localhost:26657"}).SubscribeWithReconnect(ctx, "test", tmtypes.EventQueryNewBlock)
+	if err != nil {
+		t.Fatalf("failed to subscribe, got err %v", err)
+	}
+	for _, expect := range client.events {
+		if event := <-events; event != expect {
+			t.Errorf("diff event, got %v, want %v", event, expect)
+		}
+	}
+
+	cancel()
+	<-client.stopped
+	for range events {
+	}
+	if _, ok := <-gaps; ok {
+		t.Errorf("expect gaps to be closed without a gap")
+	}
+}